@@ -1,9 +1,25 @@
 package apis
 
 import (
+	"extender-scheduler/handler"
 	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"net/http"
 )
 
-// Bind Deprecated
+// Bind binds the pod, the pods requesting a GPU slice get the GPU annotated first.
+// Only the pods of the managedResources are sent here when bindVerb is set.
 func Bind(c *gin.Context) {
+	klog.Info("begin to [Bind]...")
+
+	var args extenderv1.ExtenderBindingArgs
+	if err := c.BindJSON(&args); err != nil {
+		klog.Errorf("[bind] failed to decode result: %v", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	// 绑定失败时 scheduler 看的是 result.Error
+	res, _ := handler.Ex.Bind(args)
+	c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,34 @@
+package apis
+
+import (
+	"extender-scheduler/handler"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"net/http"
+	"strconv"
+)
+
+// Explain returns the per-node decision of the extender for the same ExtenderArgs as Filter,
+// the weight query parameter is the weight of the extender in the scheduler config, 1 by default.
+func Explain(c *gin.Context) {
+	klog.Info("begin to [Explain]...")
+
+	weight, err := strconv.ParseInt(c.DefaultQuery("weight", "1"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, "invalid weight: "+err.Error())
+		return
+	}
+	var args extenderv1.ExtenderArgs
+	if err := c.BindJSON(&args); err != nil {
+		klog.Errorf("[explain] failed to decode result: %v", err)
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	res, err := handler.Ex.Explain(args, weight)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
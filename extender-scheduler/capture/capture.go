@@ -0,0 +1,253 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// currentFile is the file records are appended to, it is renamed when it is full.
+	currentFile = "capture.jsonl"
+	filePrefix  = "capture-"
+	fileSuffix  = ".jsonl"
+)
+
+// Record is one request to the extender and its response.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Verb is the path of the request without the slash, e.g. filter.
+	Verb string `json:"verb"`
+	// Pod is the namespace/name of the pod in the ExtenderArgs.
+	Pod      string          `json:"pod,omitempty"`
+	Status   int             `json:"status"`
+	Args     json.RawMessage `json:"args"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Options configures the Recorder.
+type Options struct {
+	// Dir is the directory of the capture files.
+	Dir string
+	// SampleRate is the share of the requests captured, from 0 to 1.
+	SampleRate float64
+	// MaxRecordBytes drops the requests whose args and response are larger, 0 means no limit.
+	MaxRecordBytes int
+	// MaxFileBytes is the size a capture file is rotated at.
+	MaxFileBytes int64
+	// MaxFiles is the number of rotated files kept, the oldest ones are deleted.
+	MaxFiles int
+}
+
+// Recorder appends sampled requests and responses to rotating files in a directory.
+type Recorder struct {
+	opts Options
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRecorder opens the capture file in opts.Dir, records are appended to an existing one.
+func NewRecorder(opts Options) (*Recorder, error) {
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return nil, fmt.Errorf("sample rate %v is not between 0 and 1", opts.SampleRate)
+	}
+	if opts.MaxFileBytes <= 0 {
+		return nil, fmt.Errorf("max file size must be positive, got %d", opts.MaxFileBytes)
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	r := &Recorder{opts: opts}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// uncapturedRoutes are passed through by the Middleware, they are not scheduler calls to replay.
+var uncapturedRoutes = map[string]bool{
+	// explain 是排查用的，不是 scheduler 的调用，它的响应也不是 replay 能比较的格式
+	"/explain": true,
+	// bind 会真的绑定 Pod，不能回放
+	"/bind": true,
+}
+
+// Middleware captures the requests of the routes it is used on, but uncapturedRoutes.
+func (r *Recorder) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rand.Float64() >= r.opts.SampleRate || uncapturedRoutes[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		args, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			klog.Errorf("[capture] read request body failed: %v", err)
+		}
+		// handler 还要读一遍 body
+		c.Request.Body = io.NopCloser(bytes.NewReader(args))
+		w := &bodyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		record := Record{
+			Time:     time.Now(),
+			Verb:     strings.TrimPrefix(c.FullPath(), "/"),
+			Pod:      podName(args),
+			Status:   c.Writer.Status(),
+			Args:     rawJSON(args),
+			Response: rawJSON(w.body.Bytes()),
+		}
+		if r.opts.MaxRecordBytes > 0 && len(args)+w.body.Len() > r.opts.MaxRecordBytes {
+			klog.V(4).Infof("[capture] skip %s of pod %s, %d bytes", record.Verb, record.Pod, len(args)+w.body.Len())
+			return
+		}
+		if err := r.write(record); err != nil {
+			klog.Errorf("[capture] write record failed: %v", err)
+		}
+	}
+}
+
+// Close closes the capture file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// write appends the record, the file is rotated first when the record doesn't fit in it.
+func (r *Recorder) write(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(data)) > r.opts.MaxFileBytes {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	return err
+}
+
+// open opens the current capture file for appending.
+func (r *Recorder) open() error {
+	f, err := os.OpenFile(filepath.Join(r.opts.Dir, currentFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// rotate renames the current file after the time and deletes the oldest rotated files.
+func (r *Recorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	// 时间格式保证按文件名排序就是按时间排序
+	rotated := filePrefix + time.Now().UTC().Format("20060102T150405.000000000") + fileSuffix
+	if err := os.Rename(filepath.Join(r.opts.Dir, currentFile), filepath.Join(r.opts.Dir, rotated)); err != nil {
+		return err
+	}
+
+	files, err := rotatedFiles(r.opts.Dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > r.opts.MaxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return r.open()
+}
+
+// Files returns the capture files of the directory, oldest first.
+func Files(dir string) ([]string, error) {
+	files, err := rotatedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	current := filepath.Join(dir, currentFile)
+	if _, err := os.Stat(current); err == nil {
+		files = append(files, current)
+	}
+	return files, nil
+}
+
+// rotatedFiles returns the rotated capture files of the directory, oldest first.
+func rotatedFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, filePrefix+"*"+fileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// bodyWriter keeps a copy of the response body.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// podName returns namespace/name of the pod in the ExtenderArgs, empty if the args don't decode.
+func podName(args []byte) string {
+	var a struct {
+		Pod *struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"metadata"`
+		} `json:"pod"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil || a.Pod == nil {
+		return ""
+	}
+	return a.Pod.Metadata.Namespace + "/" + a.Pod.Metadata.Name
+}
+
+// rawJSON returns data as a JSON value, invalid JSON is kept as a string so the record stays valid.
+func rawJSON(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+	quoted, _ := json.Marshal(string(data))
+	return quoted
+}
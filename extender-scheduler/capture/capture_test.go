@@ -0,0 +1,168 @@
+package capture
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"extender-scheduler/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// post sends the ExtenderArgs fixture of the router tests to the handler.
+func post(t *testing.T, h http.Handler, verb, fixture string) {
+	t.Helper()
+	args, err := os.ReadFile("../routers/testdata/" + fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/"+verb, strings.NewReader(string(args))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s returned %d: %s", verb, w.Code, w.Body.String())
+	}
+}
+
+func TestCaptureAndReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	rec, err := NewRecorder(Options{Dir: dir, SampleRate: 1, MaxFileBytes: 1 << 20, MaxFiles: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+	post(t, routers.InitMgrRouter(rec.Middleware()), "filter", "filter/labeled-nodes.json")
+	post(t, routers.InitMgrRouter(rec.Middleware()), "explain", "explain/gpu-models.json")
+	post(t, routers.InitMgrRouter(rec.Middleware()), "prioritize", "prioritize/gpu-models.json")
+
+	files, err := Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadRecords(files...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, r.Verb+" "+r.Pod)
+	}
+	want := []string{"filter default/cuda-test-7d9f8b6c5-x2x4k", "prioritize default/cuda-test-7d9f8b6c5-x2x4k"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("captured %v, want %v", got, want)
+	}
+
+	diffs, err := Replay(routers.InitMgrRouter(), records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("unchanged policy replayed with diffs %+v", diffs)
+	}
+
+	// 假装抓到的是旧策略的结果
+	records[0].Response = json.RawMessage(`{"NodeNames":["worker-2"]}`)
+	records[1].Response = json.RawMessage(`[{"Host":"worker-1","Score":10}]`)
+	diffs, err = Replay(routers.InitMgrRouter(), records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, d := range diffs {
+		got = append(got, d.Changes...)
+	}
+	want = []string{
+		"nodes: [worker-2] -> [worker-1 worker-3]",
+		"score of worker-1: 10 -> 50",
+		"score of worker-2: none -> 80",
+		"score of worker-3: none -> 0",
+		"score of worker-5: none -> 100000",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRecorderLimits(t *testing.T) {
+	record := Record{Time: time.Now(), Verb: "filter", Status: http.StatusOK, Args: json.RawMessage(`{}`), Response: json.RawMessage(`{}`)}
+	data, _ := json.Marshal(record)
+	recordSize := int64(len(data) + 1)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		records   int
+		wantFiles int
+	}{
+		{
+			name:      "rotated when full",
+			opts:      Options{MaxFileBytes: 2 * recordSize, MaxFiles: 5},
+			records:   5,
+			wantFiles: 3,
+		},
+		{
+			name:      "oldest files deleted",
+			opts:      Options{MaxFileBytes: recordSize, MaxFiles: 2},
+			records:   5,
+			wantFiles: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dir = t.TempDir()
+			rec, err := NewRecorder(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rec.Close()
+			for i := 0; i < tt.records; i++ {
+				if err := rec.write(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files, err := Files(tt.opts.Dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("got files %v, want %d", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestMiddlewareSkips(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "not sampled", opts: Options{SampleRate: 0, MaxFileBytes: 1 << 20}},
+		{name: "too large", opts: Options{SampleRate: 1, MaxRecordBytes: 1024, MaxFileBytes: 1 << 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Dir = t.TempDir()
+			rec, err := NewRecorder(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rec.Close()
+			post(t, routers.InitMgrRouter(rec.Middleware()), "filter", "filter/labeled-nodes.json")
+
+			records, err := ReadRecords(tt.opts.Dir + "/" + currentFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 0 {
+				t.Errorf("captured %d records", len(records))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// maxLineBytes is the longest record ReadRecords accepts, ExtenderArgs of big clusters are large.
+const maxLineBytes = 64 << 20
+
+// Diff is a captured request whose response changed.
+type Diff struct {
+	Record Record
+	// Changes describe what changed, e.g. "nodes: [a b] -> [a]".
+	Changes []string
+}
+
+// ReadRecords reads the records of the capture files in order.
+func ReadRecords(files ...string) ([]Record, error) {
+	var records []Record
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(nil, maxLineBytes)
+		for line := 1; scanner.Scan(); line++ {
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+			}
+			records = append(records, record)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file, err)
+		}
+	}
+	return records, nil
+}
+
+// Replay sends the args of the records to the handler, usually the router of the current
+// policy, and returns the records whose status, chosen or filtered nodes or scores changed.
+func Replay(h http.Handler, records []Record) ([]Diff, error) {
+	var diffs []Diff
+	for _, record := range records {
+		req := httptest.NewRequest(http.MethodPost, "/"+record.Verb, bytes.NewReader(record.Args))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		changes, err := compare(record.Verb, record.Status, record.Response, w.Code, w.Body.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s of pod %s at %s: %w", record.Verb, record.Pod, record.Time, err)
+		}
+		if len(changes) != 0 {
+			diffs = append(diffs, Diff{Record: record, Changes: changes})
+		}
+	}
+	return diffs, nil
+}
+
+// compare returns the differences between the captured and the replayed response.
+func compare(verb string, oldStatus int, oldBody json.RawMessage, newStatus int, newBody []byte) ([]string, error) {
+	if oldStatus != newStatus {
+		return []string{fmt.Sprintf("status: %d -> %d", oldStatus, newStatus)}, nil
+	}
+	if oldStatus != http.StatusOK {
+		return nil, nil
+	}
+
+	switch verb {
+	case "prioritize":
+		var oldList, newList extenderv1.HostPriorityList
+		if err := unmarshal(oldBody, newBody, &oldList, &newList); err != nil {
+			return nil, err
+		}
+		return compareScores(oldList, newList), nil
+	default:
+		// filter 和 allinone 都返回 ExtenderFilterResult
+		var oldResult, newResult extenderv1.ExtenderFilterResult
+		if err := unmarshal(oldBody, newBody, &oldResult, &newResult); err != nil {
+			return nil, err
+		}
+		var changes []string
+		if o, n := chosenNodes(&oldResult), chosenNodes(&newResult); o != n {
+			changes = append(changes, fmt.Sprintf("nodes: %s -> %s", o, n))
+		}
+		if o, n := failedNodes(&oldResult), failedNodes(&newResult); o != n {
+			changes = append(changes, fmt.Sprintf("failed nodes: %s -> %s", o, n))
+		}
+		if oldResult.Error != newResult.Error {
+			changes = append(changes, fmt.Sprintf("error: %q -> %q", oldResult.Error, newResult.Error))
+		}
+		return changes, nil
+	}
+}
+
+func unmarshal(oldBody, newBody []byte, oldObj, newObj interface{}) error {
+	if err := json.Unmarshal(oldBody, oldObj); err != nil {
+		return fmt.Errorf("decode captured response: %w", err)
+	}
+	if err := json.Unmarshal(newBody, newObj); err != nil {
+		return fmt.Errorf("decode replayed response: %w", err)
+	}
+	return nil
+}
+
+// chosenNodes returns the sorted names of the nodes passing the filter.
+// NodeNames is nil when the extender gives up and returns all nodes, the Nodes are used then.
+func chosenNodes(res *extenderv1.ExtenderFilterResult) string {
+	var names []string
+	switch {
+	case res.NodeNames != nil:
+		names = append(names, *res.NodeNames...)
+	case res.Nodes != nil:
+		for _, node := range res.Nodes.Items {
+			names = append(names, node.Name)
+		}
+	}
+	sort.Strings(names)
+	return "[" + strings.Join(names, " ") + "]"
+}
+
+// failedNodes returns the sorted failed nodes with their reasons.
+func failedNodes(res *extenderv1.ExtenderFilterResult) string {
+	var failed []string
+	for name, reason := range res.FailedNodes {
+		failed = append(failed, name+": "+reason)
+	}
+	for name, reason := range res.FailedAndUnresolvableNodes {
+		failed = append(failed, name+": "+reason+" (unresolvable)")
+	}
+	sort.Strings(failed)
+	return "[" + strings.Join(failed, ", ") + "]"
+}
+
+// compareScores returns the hosts whose score changed, appeared or disappeared.
+func compareScores(oldList, newList extenderv1.HostPriorityList) []string {
+	oldScores, newScores := make(map[string]int64), make(map[string]int64)
+	for _, p := range oldList {
+		oldScores[p.Host] = p.Score
+	}
+	for _, p := range newList {
+		newScores[p.Host] = p.Score
+	}
+
+	var changes []string
+	for host, o := range oldScores {
+		n, ok := newScores[host]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("score of %s: %d -> none", host, o))
+		case n != o:
+			changes = append(changes, fmt.Sprintf("score of %s: %d -> %d", host, o, n))
+		}
+	}
+	for host, n := range newScores {
+		if _, ok := oldScores[host]; !ok {
+			changes = append(changes, fmt.Sprintf("score of %s: none -> %d", host, n))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// PrintDiffs writes the diffs and a summary line.
+func PrintDiffs(w io.Writer, total int, diffs []Diff) {
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s %s pod %s\n", d.Record.Time.Format("2006-01-02T15:04:05.000Z07:00"), d.Record.Verb, d.Record.Pod)
+		for _, change := range d.Changes {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+	}
+	fmt.Fprintf(w, "%d requests replayed, %d changed\n", total, len(diffs))
+}
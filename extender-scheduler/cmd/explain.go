@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+
+	"extender-scheduler/handler"
+)
+
+// Explain prints the per-node decision of the extender for the ExtenderArgs in the file,
+// the same as POST /explain.
+func Explain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ExitOnError)
+	weight := fs.Int64("weight", 1, "weight of the extender in the scheduler config")
+	output := fs.String("o", "text", "output format: text or json")
+	setManaged := managedFlags(fs)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: extender-scheduler explain [-weight N] [-o text|json] ARGS_FILE\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setManaged()
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		klog.Fatalf("read ExtenderArgs: %v", err)
+	}
+	var extenderArgs extenderv1.ExtenderArgs
+	if err := json.Unmarshal(data, &extenderArgs); err != nil {
+		klog.Fatalf("decode ExtenderArgs: %v", err)
+	}
+	e, err := handler.Ex.Explain(extenderArgs, *weight)
+	if err != nil {
+		klog.Fatalf("explain: %v", err)
+	}
+
+	if *output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(e); err != nil {
+			klog.Fatal(err)
+		}
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "pod %s\n", e.Pod)
+	fmt.Fprintln(w, "RANK\tNODE\tPREDICATES\tSCORES\tALLINONE RANK")
+	for _, ne := range e.Nodes {
+		var predicates, scores []string
+		for _, p := range ne.Predicates {
+			if p.Passed {
+				predicates = append(predicates, p.Name+": passed")
+			} else {
+				predicates = append(predicates, p.Name+": "+p.Reason)
+			}
+		}
+		for _, s := range ne.Scores {
+			if s.Skipped != "" {
+				scores = append(scores, s.Scorer+": skipped")
+				continue
+			}
+			scores = append(scores, fmt.Sprintf("%s: %d -> %d x%d = %d", s.Scorer, s.Raw, s.Normalized, s.Weight, s.Weighted))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", rank(ne.Rank), ne.Name, strings.Join(predicates, ", "), strings.Join(scores, ", "), rank(ne.AllInOneRank))
+	}
+	switch {
+	case e.NotManaged:
+		fmt.Fprintln(w, "pod requests no managed resource, all nodes pass")
+	case e.NoNodeFits:
+		fmt.Fprintln(w, "no node fits, filter and allinone return all nodes")
+	default:
+		fmt.Fprintf(w, "allinone picks %s\n", e.AllInOneNode)
+	}
+	if err := w.Flush(); err != nil {
+		klog.Fatal(err)
+	}
+	return 0
+}
+
+// rank formats a rank, 0 means not ranked.
+func rank(r int) string {
+	if r == 0 {
+		return "-"
+	}
+	return fmt.Sprint(r)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"flag"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"extender-scheduler/capture"
+	"extender-scheduler/routers"
+)
+
+// Replay feeds the captured requests through the current policy and prints what changed,
+// it returns 1 when a response changed so it can gate a fix in CI.
+func Replay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	dir := fs.String("dir", "", "capture directory, all its capture files are replayed")
+	setManaged := managedFlags(fs)
+	fs.Usage = func() {
+		fs.Output().Write([]byte("usage: extender-scheduler replay [-dir DIR] [FILE...]\n"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	setManaged()
+
+	files := fs.Args()
+	if *dir != "" {
+		dirFiles, err := capture.Files(*dir)
+		if err != nil {
+			klog.Fatalf("list capture files: %v", err)
+		}
+		files = append(dirFiles, files...)
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	records, err := capture.ReadRecords(files...)
+	if err != nil {
+		klog.Fatalf("read captures: %v", err)
+	}
+	gin.SetMode(gin.ReleaseMode)
+	diffs, err := capture.Replay(routers.InitMgrRouter(), records)
+	if err != nil {
+		klog.Fatalf("replay: %v", err)
+	}
+	capture.PrintDiffs(os.Stdout, len(records), diffs)
+	if len(diffs) != 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+// Package cmd holds the replay and explain subcommands of extender-scheduler and the
+// managed resource flags they share with the server.
+package cmd
+
+import (
+	"flag"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"extender-scheduler/handler"
+)
+
+// managedFlags adds the managed resource flags of the server to a subcommand, without the pod
+// informer the ignored resources and GPU slices are only checked against the capacity of the nodes there.
+// The returned func sets handler.ManagedResources once the flags are parsed.
+func managedFlags(fs *flag.FlagSet) func() {
+	managed := fs.String("managed-resources", "", "same as the flag of the server")
+	ignored := fs.String("ignored-by-scheduler-resources", "", "same as the flag of the server")
+	gpuSlice := fs.String("gpu-slice-resource", "", "same as the flag of the server")
+	gpuTopology := fs.String("gpu-topology-resource", "", "same as the flag of the server")
+	return func() {
+		ConfigureResources(*managed, *ignored, *gpuSlice, *gpuTopology)
+	}
+}
+
+// ConfigureResources sets the managed resources, GPU slicing and topology aware placement of
+// the handlers from the flags. The GPU slice resource is managed and ignored by the scheduler,
+// the GPU topology resource is managed and still checked by the scheduler.
+func ConfigureResources(managed, ignored, gpuSlice, gpuTopology string) {
+	if gpuSlice != "" {
+		handler.Slices = handler.NewGPUSlices(v1.ResourceName(gpuSlice))
+		ignored += "," + gpuSlice
+	}
+	if gpuTopology != "" {
+		handler.Topology = handler.NewGPUTopology(v1.ResourceName(gpuTopology))
+		managed += "," + gpuTopology
+	}
+	handler.ManagedResources = managedResources(managed, ignored)
+}
+
+// NeedsPodCache returns true when the handlers count what is allocated on the nodes.
+func NeedsPodCache() bool {
+	if handler.Slices != nil || handler.Topology != nil {
+		return true
+	}
+	for _, r := range handler.ManagedResources {
+		if r.IgnoredByScheduler {
+			return true
+		}
+	}
+	return false
+}
+
+// managedResources parses the -managed-resources and -ignored-by-scheduler-resources flags,
+// the ignored resources are managed too.
+func managedResources(managed, ignored string) []handler.ManagedResource {
+	var resources []handler.ManagedResource
+	index := make(map[string]int)
+	for _, name := range splitList(managed) {
+		if _, ok := index[name]; !ok {
+			index[name] = len(resources)
+			resources = append(resources, handler.ManagedResource{Name: v1.ResourceName(name)})
+		}
+	}
+	for _, name := range splitList(ignored) {
+		if i, ok := index[name]; ok {
+			resources[i].IgnoredByScheduler = true
+			continue
+		}
+		index[name] = len(resources)
+		resources = append(resources, handler.ManagedResource{Name: v1.ResourceName(name), IgnoredByScheduler: true})
+	}
+	return resources
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
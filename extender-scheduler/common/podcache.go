@@ -0,0 +1,111 @@
+package common
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const nodeNameIndex = "nodeName"
+
+// PodCache 按节点索引已经绑定的 Pod，用来统计 scheduler 不检查（ignoredByScheduler）的资源用量
+type PodCache struct {
+	indexer cache.Indexer
+}
+
+// NewPodCache starts an informer of the pods and waits for it to sync.
+func NewPodCache(clientset kubernetes.Interface, stopCh <-chan struct{}) (*PodCache, error) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	informer := factory.Core().V1().Pods().Informer()
+	err := informer.AddIndexers(cache.Indexers{nodeNameIndex: func(obj interface{}) ([]string, error) {
+		pod, ok := obj.(*v1.Pod)
+		if !ok || pod.Spec.NodeName == "" {
+			return nil, nil
+		}
+		return []string{pod.Spec.NodeName}, nil
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return nil, fmt.Errorf("pod informer not synced")
+	}
+	return &PodCache{indexer: informer.GetIndexer()}, nil
+}
+
+// PodsOnNode returns the pods bound to the node which are not finished, nil for a nil cache.
+func (c *PodCache) PodsOnNode(nodeName string) []*v1.Pod {
+	if c == nil {
+		return nil
+	}
+	objs, err := c.indexer.ByIndex(nodeNameIndex, nodeName)
+	if err != nil {
+		return nil
+	}
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pod := obj.(*v1.Pod)
+		// 已经结束的 Pod 不再占用资源
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// Get returns the pod from the cache, false when it's not there or the cache is nil.
+func (c *PodCache) Get(namespace, name string) (*v1.Pod, bool) {
+	if c == nil {
+		return nil, false
+	}
+	obj, exists, err := c.indexer.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil, false
+	}
+	return obj.(*v1.Pod), true
+}
+
+// Allocated returns the amount of the resource requested by the running pods on the node,
+// 0 for a nil cache.
+func (c *PodCache) Allocated(nodeName string, name v1.ResourceName) int64 {
+	var total int64
+	for _, pod := range c.PodsOnNode(nodeName) {
+		total += PodRequest(pod, name)
+	}
+	return total
+}
+
+// PodRequest returns the amount of the resource the pod requests: the larger of the sum of
+// the containers and the largest init container. Extended resources can't be overcommitted,
+// so a limit without a request counts as the request.
+func PodRequest(pod *v1.Pod, name v1.ResourceName) int64 {
+	var sum, initMax int64
+	for _, c := range pod.Spec.Containers {
+		sum += containerRequest(c, name)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if r := containerRequest(c, name); r > initMax {
+			initMax = r
+		}
+	}
+	if initMax > sum {
+		return initMax
+	}
+	return sum
+}
+
+func containerRequest(c v1.Container, name v1.ResourceName) int64 {
+	if q, ok := c.Resources.Requests[name]; ok {
+		return q.Value()
+	}
+	if q, ok := c.Resources.Limits[name]; ok {
+		return q.Value()
+	}
+	return 0
+}
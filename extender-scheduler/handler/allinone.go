@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"errors"
+
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
@@ -31,40 +33,57 @@ func (l NodeScoreList) Less(i, j int) bool {
 
 // FilterOnlyOne 过滤掉不满足条件的节点,并将其余节点打分排序，最终只返回得分最高的节点以实现完全控制调度结果
 func (ex *Extender) FilterOnlyOne(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error) {
+	if !isManaged(args.Pod) {
+		return passThrough(args), nil
+	}
+	if args.Nodes == nil {
+		return nil, errors.New("allinone needs ExtenderArgs.Nodes, nodeCacheCapable extenders are not supported")
+	}
 	// 过滤掉不满足条件的节点
-	nodeScores := &NodeScoreList{NodeList: make([]*NodeScore, 0)}
-
+	nodes := make([]v1.Node, 0)
 	for _, node := range args.Nodes.Items {
-		_, ok := node.Labels[Label]
-		if !ok { // 排除掉不带指定标签的节点
+		if ok, _ := fits(args.Pod, node); !ok { // 排除掉不满足条件的节点
 			continue
 		}
-		// 对剩余节点打分
-		score := ComputeScore(node)
-		nodeScores.NodeList = append(nodeScores.NodeList, &NodeScore{Node: node, Score: score})
+		nodes = append(nodes, node)
 	}
 	// 没有满足条件的节点就报错
-	if len(nodeScores.NodeList) == 0 {
+	if len(nodes) == 0 {
 		return &extenderv1.ExtenderFilterResult{
 			Nodes: args.Nodes,
 			//NodeNames: args.NodeNames,
 			NodeNames: nil,
 		}, nil
 	}
-	// 排序
-	sort.Sort(nodeScores)
-	// 然后取最后一个，即得分最高的节点，这样由于 Filter 只返回了一个节点，因此最终肯定会调度到该节点上
-	m := (*nodeScores).NodeList[len((*nodeScores).NodeList)-1]
+	// 对剩余节点打分排序，取第一个，即得分最高的节点，这样由于 Filter 只返回了一个节点，因此最终肯定会调度到该节点上
+	m := rankByScore(nodes).NodeList[0]
 
 	// 组装一下返回结果
-	args.Nodes.Items = []v1.Node{m.Node}
+	filtered := *args.Nodes
+	filtered.Items = []v1.Node{m.Node}
 
 	return &extenderv1.ExtenderFilterResult{
-		Nodes:     args.Nodes,
+		Nodes:     &filtered,
 		NodeNames: &[]string{m.Node.Name},
 	}, nil
 }
 
+// rankByScore scores the nodes with ComputeScore and sorts them from the highest score.
+// Of the nodes with the same score the last one in args ranks first, FilterOnlyOne used to
+// sort them ascending and take the last node.
+func rankByScore(nodes []v1.Node) NodeScoreList {
+	nodeScores := NodeScoreList{NodeList: make([]*NodeScore, 0, len(nodes))}
+	for _, node := range nodes {
+		nodeScores.NodeList = append(nodeScores.NodeList, &NodeScore{Node: node, Score: ComputeScore(node)})
+	}
+	sort.Stable(nodeScores)
+	// 升序稳定排序后反过来，分数相同时靠后的节点排在前面
+	for i, j := 0, len(nodeScores.NodeList)-1; i < j; i, j = i+1, j-1 {
+		nodeScores.Swap(i, j)
+	}
+	return nodeScores
+}
+
 func ComputeScore(node v1.Node) int64 {
 	// 获取 Node 上的 Label 作为分数
 	priorityStr, ok := node.Labels[Label]
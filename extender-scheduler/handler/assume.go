@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// assumeExpiry is how long a pod assumed by Bind is kept while the pod cache doesn't have it:
+// the informer may see the pod late, and a pod deleted right after Bind is never seen bound.
+const assumeExpiry = time.Minute
+
+// assumeCache holds what Bind allocated to the pods the pod cache doesn't account for yet,
+// GPUSlices and GPUTopology each keep their allocations in one. An entry is dropped by forget
+// when the binding fails, and on every lookup once the pod cache shows the pod bound or finished,
+// or after assumeExpiry when the pod isn't in the pod cache.
+type assumeCache[T any] struct {
+	mu   sync.Mutex
+	pods map[types.UID]assumedPod[T]
+	// now is time.Now, tests replace it.
+	now func() time.Time
+}
+
+type assumedPod[T any] struct {
+	namespace string
+	name      string
+	node      string
+	since     time.Time
+	alloc     T
+}
+
+func newAssumeCache[T any]() *assumeCache[T] {
+	return &assumeCache[T]{pods: make(map[types.UID]assumedPod[T]), now: time.Now}
+}
+
+// assume records the allocation of the pod on the node.
+func (c *assumeCache[T]) assume(pod *v1.Pod, node string, alloc T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pods[pod.UID] = assumedPod[T]{namespace: pod.Namespace, name: pod.Name, node: node, since: c.now(), alloc: alloc}
+}
+
+// forget drops the allocation of the pod.
+func (c *assumeCache[T]) forget(uid types.UID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pods, uid)
+}
+
+// onNode returns the allocations of the pods assumed on the node. The entries of all nodes
+// the pod cache accounts for are dropped first, so they don't pile up on nodes not filtered again.
+// 调用方要先取 assumed 再查 pod cache：中间绑定好的 pod 会被算两次，不会漏掉
+func (c *assumeCache[T]) onNode(node string) []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var allocs []T
+	for uid, a := range c.pods {
+		if settled(uid, a.namespace, a.name, now.Sub(a.since)) {
+			delete(c.pods, uid)
+			continue
+		}
+		if a.node == node {
+			allocs = append(allocs, a.alloc)
+		}
+	}
+	return allocs
+}
+
+// settled returns true when the pod assumed for the duration no longer needs to be: the pod
+// cache shows it bound, so PodsOnNode counts it, or finished, or it has been missing for assumeExpiry.
+func settled(uid types.UID, namespace, name string, assumed time.Duration) bool {
+	pod, ok := Pods.Get(namespace, name)
+	if !ok || pod.UID != uid {
+		// pod cache 还没看到这个 pod，或者 pod 已经删掉了（同名重建的也一样）
+		return assumed > assumeExpiry
+	}
+	return pod.Spec.NodeName != "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
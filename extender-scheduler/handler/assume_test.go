@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAssumeCachePrunes(t *testing.T) {
+	finished := slicePod("finished", "", 1000, "")
+	finished.Status.Phase = v1.PodSucceeded
+	client := fake.NewSimpleClientset(
+		slicePod("pending", "", 1000, ""),
+		slicePod("bound", "n2", 1000, "0"),
+		finished,
+	)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	pods, err := common.NewPodCache(client, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setManaged(t, nil, pods)
+
+	now := time.Now()
+	c := newAssumeCache[int]()
+	c.now = func() time.Time { return now }
+	// deleted 不在 pod cache 里，可能是 informer 还没看到，也可能已经删掉了
+	for i, name := range []string{"pending", "bound", "finished", "deleted"} {
+		c.assume(slicePod(name, "", 1000, ""), "n1", i)
+	}
+
+	// bound 绑到了 n2，查 n3 时也要清掉
+	if got := c.onNode("n3"); len(got) != 0 {
+		t.Errorf("n3 has assumed %v", got)
+	}
+	got := c.onNode("n1")
+	sort.Ints(got)
+	if want := []int{0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("n1 has assumed %v, want %v", got, want)
+	}
+
+	now = now.Add(assumeExpiry + time.Second)
+	if got, want := c.onNode("n1"), []int{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("n1 has assumed %v after %v, want %v", got, assumeExpiry, want)
+	}
+	c.forget("pending")
+	if len(c.pods) != 0 {
+		t.Errorf("%d pods still assumed", len(c.pods))
+	}
+}
@@ -2,30 +2,114 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"extender-scheduler/common"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
-	"log"
 )
 
 // Bind 将 Pod 绑定到指定节点
+// 请求了 GPU 切片或者多块 GPU 的 Pod 先选好 GPU 写到 annotation 上，再绑定
 func (ex *Extender) Bind(args extenderv1.ExtenderBindingArgs) (*extenderv1.ExtenderBindingResult, error) {
 	log.Printf("bind pod: %s/%s to node:%s", args.PodNamespace, args.PodName, args.Node)
+	result := new(extenderv1.ExtenderBindingResult)
+	if ex == nil || ex.ClientSet == nil {
+		err := errors.New("bind needs a kube client, the extender runs without one")
+		result.Error = err.Error()
+		return result, err
+	}
+
+	ctx := context.Background()
+	if Slices != nil || Topology != nil {
+		if err := ex.allocateGPUs(ctx, args); err != nil {
+			klog.ErrorS(err, "Failed to allocate GPUs", "pod", args.PodName, "namespace", args.PodNamespace, "node", args.Node)
+			result.Error = err.Error()
+			return result, err
+		}
+	}
 
 	// 创建绑定关系
 	binding := &corev1.Binding{
 		ObjectMeta: metav1.ObjectMeta{Name: args.PodName, Namespace: args.PodNamespace, UID: args.PodUID},
 		Target:     corev1.ObjectReference{Kind: "Node", APIVersion: "v1", Name: args.Node},
 	}
-
-	result := new(extenderv1.ExtenderBindingResult)
-	err := ex.ClientSet.CoreV1().Pods(args.PodNamespace).Bind(context.Background(), binding, metav1.CreateOptions{})
+	err := ex.ClientSet.CoreV1().Pods(args.PodNamespace).Bind(ctx, binding, metav1.CreateOptions{})
 	if err != nil {
 		klog.ErrorS(err, "Failed to bind pod", "pod", args.PodName, "namespace", args.PodNamespace, "podUID", args.PodUID, "node", args.Node)
+		forgetGPUs(args.PodUID)
 		result.Error = err.Error()
 		return result, err
 	}
-
 	return result, nil
 }
+
+// allocateGPUs picks the GPUs of the node for the pod requesting a GPU slice or GPUs of
+// Topology.Resource, and records them in GPUDeviceAnnotation and GPUDevicesAnnotation.
+func (ex *Extender) allocateGPUs(ctx context.Context, args extenderv1.ExtenderBindingArgs) error {
+	pod, ok := Pods.Get(args.PodNamespace, args.PodName)
+	if !ok || pod.UID != args.PodUID {
+		var err error
+		if pod, err = ex.ClientSet.CoreV1().Pods(args.PodNamespace).Get(ctx, args.PodName, metav1.GetOptions{}); err != nil {
+			return err
+		}
+	}
+	wantSlice := Slices != nil && common.PodRequest(pod, Slices.Resource) > 0
+	wantDevices := Topology != nil && common.PodRequest(pod, Topology.Resource) > 0
+	if !wantSlice && !wantDevices {
+		return nil
+	}
+	node, err := ex.ClientSet.CoreV1().Nodes().Get(ctx, args.Node, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	annotations := make(map[string]string)
+	if wantSlice {
+		device, err := Slices.assume(pod, node)
+		if err != nil {
+			return err
+		}
+		annotations[GPUDeviceAnnotation] = strconv.Itoa(device)
+	}
+	if wantDevices {
+		devices, err := Topology.assume(pod, node)
+		if err != nil {
+			forgetGPUs(pod.UID)
+			return err
+		}
+		var items []string
+		for _, d := range devices {
+			items = append(items, strconv.Itoa(d))
+		}
+		annotations[GPUDevicesAnnotation] = strings.Join(items, ",")
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if _, err := ex.ClientSet.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		forgetGPUs(pod.UID)
+		return fmt.Errorf("annotate GPUs %v: %w", annotations, err)
+	}
+	klog.InfoS("Allocated GPUs", "pod", klog.KObj(pod), "node", args.Node, "annotations", annotations)
+	return nil
+}
+
+// forgetGPUs drops the GPUs assumed for the pod.
+func forgetGPUs(uid types.UID) {
+	if Slices != nil {
+		Slices.forget(uid)
+	}
+	if Topology != nil {
+		Topology.forget(uid)
+	}
+}
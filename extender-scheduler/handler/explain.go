@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// maxNodeScore is framework.MaxNodeScore of kube-scheduler, the scale the extender scores are converted to.
+const maxNodeScore int64 = 100
+
+// Explanation is how the extender decides for each node, see Explain.
+type Explanation struct {
+	Pod string `json:"pod"`
+	// Nodes are sorted by Rank, the nodes failing a predicate are last.
+	Nodes []NodeExplanation `json:"nodes"`
+	// NoNodeFits is true when no node meets the predicates, Filter and FilterOnlyOne
+	// then return all nodes and leave the choice to the scheduler.
+	NoNodeFits bool `json:"noNodeFits"`
+	// AllInOneNode is the node FilterOnlyOne returns, empty when NoNodeFits.
+	AllInOneNode string `json:"allInOneNode,omitempty"`
+	// NotManaged is true when the pod requests none of the ManagedResources, the handlers
+	// then pass all nodes without checking or scoring them.
+	NotManaged bool `json:"notManaged,omitempty"`
+}
+
+// NodeExplanation is the decision of the extender for one node.
+type NodeExplanation struct {
+	Name       string            `json:"name"`
+	Passed     bool              `json:"passed"`
+	Predicates []PredicateResult `json:"predicates"`
+	Scores     []ScoreResult     `json:"scores,omitempty"`
+	// Rank is the position of the node by the Prioritize score among the nodes Filter returns,
+	// from 1, 0 for the nodes Filter drops.
+	Rank int `json:"rank,omitempty"`
+	// AllInOneRank is the position of the node by the FilterOnlyOne score, 1 is the node it returns.
+	AllInOneRank int `json:"allInOneRank,omitempty"`
+}
+
+// PredicateResult is the outcome of one predicate.
+type PredicateResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ScoreResult is the score of one scorer.
+type ScoreResult struct {
+	Scorer string `json:"scorer"`
+	// Raw is the score the extender computes.
+	Raw int64 `json:"raw"`
+	// Normalized is Raw on the node score scale of kube-scheduler: the scheduler multiplies the
+	// Prioritize scores by MaxNodeScore/MaxExtenderPriority without clamping them, the
+	// FilterOnlyOne scores are only compared with each other so they are scaled to the highest one.
+	Normalized int64 `json:"normalized"`
+	Weight     int64 `json:"weight"`
+	// Weighted is Normalized times Weight, what the scheduler adds to the node score.
+	Weighted int64 `json:"weighted"`
+	// Skipped tells why the scorer doesn't score the node.
+	Skipped string `json:"skipped,omitempty"`
+}
+
+// Explain runs the predicates and scorers of Filter, Prioritize and FilterOnlyOne on each node,
+// weight is the weight of the extender in the scheduler config.
+func (ex *Extender) Explain(args extenderv1.ExtenderArgs, weight int64) (*Explanation, error) {
+	if args.Nodes == nil {
+		return nil, errors.New("explain needs ExtenderArgs.Nodes, nodeCacheCapable extenders are not supported")
+	}
+
+	e := &Explanation{}
+	if args.Pod != nil {
+		e.Pod = args.Pod.Namespace + "/" + args.Pod.Name
+	}
+	if !isManaged(args.Pod) {
+		e.NotManaged = true
+		for _, node := range args.Nodes.Items {
+			e.Nodes = append(e.Nodes, NodeExplanation{Name: node.Name, Passed: true})
+		}
+		return e, nil
+	}
+
+	var fitting []v1.Node
+	for _, node := range args.Nodes.Items {
+		ne := NodeExplanation{Name: node.Name, Passed: true}
+		for _, p := range activePredicates() {
+			ok, reason := p.fit(args.Pod, node)
+			ne.Predicates = append(ne.Predicates, PredicateResult{Name: p.name, Passed: ok, Reason: reason})
+			ne.Passed = ne.Passed && ok
+		}
+		if ne.Passed {
+			fitting = append(fitting, node)
+		}
+		e.Nodes = append(e.Nodes, ne)
+	}
+	e.NoNodeFits = len(fitting) == 0
+
+	// 没有节点满足条件时 Filter 返回所有节点，Prioritize 也会给所有节点打分
+	candidates := fitting
+	if e.NoNodeFits {
+		candidates = args.Nodes.Items
+	}
+	byName := make(map[string]*NodeExplanation, len(e.Nodes))
+	for i := range e.Nodes {
+		byName[e.Nodes[i].Name] = &e.Nodes[i]
+	}
+
+	explainPrioritize(args.Pod, candidates, weight, byName)
+	if !e.NoNodeFits {
+		explainAllInOne(fitting, byName)
+		e.AllInOneNode = rankByScore(fitting).NodeList[0].Node.Name
+	}
+
+	sort.SliceStable(e.Nodes, func(i, j int) bool {
+		return rankKey(e.Nodes[i]) < rankKey(e.Nodes[j])
+	})
+	return e, nil
+}
+
+// explainPrioritize adds the Prioritize scores of the candidates and ranks them.
+func explainPrioritize(pod *v1.Pod, candidates []v1.Node, weight int64, byName map[string]*NodeExplanation) {
+	type scored struct {
+		name     string
+		weighted int64
+	}
+	var ranked []scored
+	for _, node := range candidates {
+		var results []ScoreResult
+		var weighted int64
+		scoredByAny := false
+		for _, sc := range activeScorers() {
+			sr := ScoreResult{Scorer: sc.name, Weight: weight}
+			if raw, ok := sc.score(pod, node); ok {
+				sr.Raw = raw
+				sr.Normalized = raw * (maxNodeScore / extenderv1.MaxExtenderPriority)
+				sr.Weighted = sr.Normalized * weight
+				weighted += sr.Weighted
+				scoredByAny = true
+			} else {
+				sr.Skipped = "the scorer doesn't score the node"
+			}
+			results = append(results, sr)
+		}
+		if !scoredByAny {
+			for i := range results {
+				results[i].Skipped = "not in the Prioritize result, the node gets no extender score"
+			}
+		}
+		byName[node.Name].Scores = append(byName[node.Name].Scores, results...)
+		ranked = append(ranked, scored{name: node.Name, weighted: weighted})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].weighted > ranked[j].weighted })
+	for i, r := range ranked {
+		byName[r.name].Rank = i + 1
+	}
+}
+
+// explainAllInOne adds the FilterOnlyOne scores of the fitting nodes, ranked like FilterOnlyOne does.
+func explainAllInOne(fitting []v1.Node, byName map[string]*NodeExplanation) {
+	ranked := rankByScore(fitting)
+	highest := ranked.NodeList[0].Score
+	for i, ns := range ranked.NodeList {
+		sr := ScoreResult{Scorer: "FilterOnlyOne", Raw: ns.Score, Weight: 1}
+		if highest > 0 {
+			sr.Normalized = ns.Score * maxNodeScore / highest
+		}
+		sr.Weighted = sr.Normalized
+		ne := byName[ns.Node.Name]
+		ne.Scores = append(ne.Scores, sr)
+		ne.AllInOneRank = i + 1
+	}
+}
+
+// rankKey orders the ranked nodes first, the nodes failing a predicate after them.
+func rankKey(ne NodeExplanation) int {
+	if ne.Rank == 0 {
+		return int(^uint(0) >> 1)
+	}
+	return ne.Rank
+}
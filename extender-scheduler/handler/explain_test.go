@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// TestExplainMatchesHandlers checks Explain tells what Filter, Prioritize and FilterOnlyOne do.
+func TestExplainMatchesHandlers(t *testing.T) {
+	args := makeArgs()
+	args.Nodes.Items = append(args.Nodes.Items,
+		v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n4", Labels: map[string]string{Label: "ampere-a100"}}},
+		v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n5", Labels: map[string]string{Label: "30"}}},
+	)
+
+	e, err := Ex.Explain(args, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filtered, _ := Ex.Filter(makeArgsWith(args))
+	var passed []string
+	for _, ne := range e.Nodes {
+		if ne.Passed {
+			passed = append(passed, ne.Name)
+		}
+	}
+	sort.Strings(passed)
+	if !reflect.DeepEqual(passed, *filtered.NodeNames) {
+		t.Errorf("explained passing nodes %v, Filter returned %v", passed, *filtered.NodeNames)
+	}
+
+	one, _ := Ex.FilterOnlyOne(makeArgsWith(args))
+	if e.AllInOneNode != (*one.NodeNames)[0] {
+		t.Errorf("explained allinone node %s, FilterOnlyOne returned %v", e.AllInOneNode, *one.NodeNames)
+	}
+
+	filteredArgs := makeArgsWith(args)
+	filteredArgs.Nodes = filtered.Nodes
+	priorities, _ := Ex.Prioritize(filteredArgs)
+	for _, p := range *priorities {
+		for _, ne := range e.Nodes {
+			if ne.Name != p.Host {
+				continue
+			}
+			if s := ne.Scores[0]; s.Raw != p.Score || s.Weighted != p.Score*10*2 {
+				t.Errorf("explained Prioritize score of %s %+v, Prioritize returned %d", p.Host, s, p.Score)
+			}
+		}
+	}
+	if e.Nodes[0].Name != "n4" || e.Nodes[0].Rank != 1 {
+		t.Errorf("first node %s rank %d, want n4 rank 1", e.Nodes[0].Name, e.Nodes[0].Rank)
+	}
+}
+
+// makeArgsWith returns a copy of args, the handlers get a node list of their own.
+func makeArgsWith(args extenderv1.ExtenderArgs) extenderv1.ExtenderArgs {
+	nodes := *args.Nodes
+	nodes.Items = append([]v1.Node(nil), args.Nodes.Items...)
+	args.Nodes = &nodes
+	return args
+}
@@ -14,7 +14,7 @@ const Label = "nvidia.GPU"
 var Ex *Extender
 
 type Extender struct {
-	ClientSet *kubernetes.Clientset
+	ClientSet kubernetes.Interface
 }
 
 func NewExtender() {
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
@@ -13,6 +14,10 @@ func (ex *Extender) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 	nodes := make([]v1.Node, 0)
 	nodeNames := make([]string, 0)
 
+	// 不请求 managed 资源的 Pod 不归 extender 管，所有节点都放行
+	if !isManaged(args.Pod) {
+		return passThrough(args), nil
+	}
 	if args.Nodes == nil && args.NodeNames == nil {
 		return &extenderv1.ExtenderFilterResult{
 			Nodes:     args.Nodes,
@@ -20,9 +25,8 @@ func (ex *Extender) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 		}, nil
 	}
 	for _, node := range args.Nodes.Items {
-		klog.Infof("node name: %s not found %s, skip\n", node.Name, Label)
-		_, ok := node.Labels[Label]
-		if !ok { // 排除掉不带指定标签的节点
+		if ok, reason := fits(args.Pod, node); !ok { // 排除掉不满足条件的节点
+			klog.Infof("node %s: %s, skip\n", node.Name, reason)
 			continue
 		}
 		nodes = append(nodes, node)
@@ -39,10 +43,43 @@ func (ex *Extender) Filter(args extenderv1.ExtenderArgs) (*extenderv1.ExtenderFi
 		}, nil
 	}
 
-	args.Nodes.Items = nodes
+	// 复制一份 NodeList，不改调用方传进来的 args
+	filtered := *args.Nodes
+	filtered.Items = nodes
 
 	return &extenderv1.ExtenderFilterResult{
-		Nodes:     args.Nodes,
+		Nodes:     &filtered,
 		NodeNames: &nodeNames,
 	}, nil
 }
+
+// predicate is a condition a node must meet to pass Filter and FilterOnlyOne.
+// fit returns the reason when the node doesn't meet it.
+type predicate struct {
+	name string
+	fit  func(pod *v1.Pod, node v1.Node) (bool, string)
+}
+
+// predicates are checked in order by fits and Explain, see activePredicates for the ones
+// depending on ManagedResources.
+var predicates = []predicate{
+	{name: "HasGPULabel", fit: hasGPULabel},
+}
+
+// hasGPULabel 只保留带 GPU 标签的节点
+func hasGPULabel(_ *v1.Pod, node v1.Node) (bool, string) {
+	if _, ok := node.Labels[Label]; !ok {
+		return false, fmt.Sprintf("node does not have label %s", Label)
+	}
+	return true, ""
+}
+
+// fits returns true when the node meets all predicates, or else the reason of the first failed one.
+func fits(pod *v1.Pod, node v1.Node) (bool, string) {
+	for _, p := range activePredicates() {
+		if ok, reason := p.fit(pod, node); !ok {
+			return false, reason
+		}
+	}
+	return true, ""
+}
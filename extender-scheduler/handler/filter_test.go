@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+func makeArgs() extenderv1.ExtenderArgs {
+	return extenderv1.ExtenderArgs{
+		Pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "default"}},
+		Nodes: &v1.NodeList{Items: []v1.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "n1", Labels: map[string]string{Label: "10"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "n2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "n3", Labels: map[string]string{Label: "30"}}},
+		}},
+	}
+}
+
+// TestFilterKeepsArgs checks the filters return new node lists instead of editing the args.
+func TestFilterKeepsArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    func(extenderv1.ExtenderArgs) (*extenderv1.ExtenderFilterResult, error)
+		wantNodes []string
+	}{
+		{name: "Filter", filter: Ex.Filter, wantNodes: []string{"n1", "n3"}},
+		{name: "FilterOnlyOne", filter: Ex.FilterOnlyOne, wantNodes: []string{"n3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := makeArgs()
+			res, err := tt.filter(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, node := range res.Nodes.Items {
+				got = append(got, node.Name)
+			}
+			if !reflect.DeepEqual(got, tt.wantNodes) {
+				t.Errorf("nodes = %v, want %v", got, tt.wantNodes)
+			}
+			if !reflect.DeepEqual(args, makeArgs()) {
+				t.Errorf("args changed to %+v", args.Nodes.Items)
+			}
+		})
+	}
+}
+
+// TestFilterOnlyOneTie checks the last of the nodes with the highest score wins, as before the ranking was shared with Explain.
+func TestFilterOnlyOneTie(t *testing.T) {
+	args := makeArgs()
+	args.Nodes.Items[0].Labels[Label] = "30"
+	res, err := Ex.FilterOnlyOne(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *res.NodeNames; !reflect.DeepEqual(got, []string{"n3"}) {
+		t.Errorf("node names = %v, want [n3]", got)
+	}
+}
+
+// TestNodeCacheCapableArgs checks the handlers needing the nodes fail instead of panicking
+// on ExtenderArgs with node names only.
+func TestNodeCacheCapableArgs(t *testing.T) {
+	args := makeArgs()
+	args.Nodes, args.NodeNames = nil, &[]string{"n1", "n2", "n3"}
+	if _, err := Ex.FilterOnlyOne(args); err == nil {
+		t.Error("FilterOnlyOne succeeded without nodes")
+	}
+	if _, err := Ex.Prioritize(args); err == nil {
+		t.Error("Prioritize succeeded without nodes")
+	}
+}
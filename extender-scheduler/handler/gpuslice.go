@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// GPUSlicesAnnotation on a node lists the capacity of the slice resource of each GPU by
+	// device index, e.g. "16384,16384" for two 16GiB GPUs sliced by MiB, or "100,100" by percent.
+	GPUSlicesAnnotation = "toys.io/gpu-slices"
+	// GPUDeviceAnnotation is set on the pod by Bind to the index of the GPU its slice is taken
+	// from, for the device plugin to expose that GPU to the pod.
+	GPUDeviceAnnotation = "toys.io/gpu-device"
+)
+
+// Slices tracks the GPU slices allocated on each GPU, nil when GPU slicing is off.
+var Slices *GPUSlices
+
+// GPUSlices manages Resource, a virtual resource the scheduler doesn't know, e.g. GPU memory
+// in MiB: a pod requesting it gets a slice of a single GPU.
+type GPUSlices struct {
+	Resource v1.ResourceName
+
+	// assumeMu serializes assume.
+	assumeMu sync.Mutex
+	// assumed are the slices Bind allocated to the pods the pod cache doesn't show bound yet.
+	assumed *assumeCache[sliceAllocation]
+}
+
+type sliceAllocation struct {
+	device int
+	amount int64
+}
+
+// NewGPUSlices returns the slice tracker of the resource.
+func NewGPUSlices(resource v1.ResourceName) *GPUSlices {
+	return &GPUSlices{Resource: resource, assumed: newAssumeCache[sliceAllocation]()}
+}
+
+// deviceCapacity parses GPUSlicesAnnotation of the node, nil when the node has no GPU to slice.
+func deviceCapacity(node *v1.Node) ([]int64, error) {
+	value, ok := node.Annotations[GPUSlicesAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	var capacity []int64
+	for i, item := range strings.Split(value, ",") {
+		c, err := strconv.ParseInt(strings.TrimSpace(item), 10, 64)
+		if err != nil || c < 0 {
+			return nil, fmt.Errorf("invalid capacity %q of GPU %d in annotation %s", item, i, GPUSlicesAnnotation)
+		}
+		capacity = append(capacity, c)
+	}
+	return capacity, nil
+}
+
+// free returns the free slice capacity of each GPU of the node: the capacity minus the slices
+// of the pods on the node and of the assumed pods.
+func (s *GPUSlices) free(node *v1.Node) ([]int64, error) {
+	free, err := deviceCapacity(node)
+	if err != nil || free == nil {
+		return nil, err
+	}
+	take := func(device int, amount int64) {
+		// 设备数变少了的话按不存在的设备处理，不影响其他设备
+		if device >= 0 && device < len(free) {
+			free[device] -= amount
+		}
+	}
+
+	for _, a := range s.assumed.onNode(node.Name) {
+		take(a.device, a.amount)
+	}
+	for _, pod := range Pods.PodsOnNode(node.Name) {
+		device, ok := podDevice(pod)
+		if !ok {
+			continue
+		}
+		take(device, common.PodRequest(pod, s.Resource))
+	}
+	return free, nil
+}
+
+// podDevice returns GPUDeviceAnnotation of the pod.
+func podDevice(pod *v1.Pod) (int, bool) {
+	value, ok := pod.Annotations[GPUDeviceAnnotation]
+	if !ok {
+		return 0, false
+	}
+	device, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return device, true
+}
+
+// pickDevice returns the GPU whose free capacity fits the request most tightly, leaving the
+// larger free slices to larger requests; -1 when no single GPU fits.
+func pickDevice(free []int64, request int64) int {
+	best := -1
+	for i, f := range free {
+		if f >= request && (best == -1 || f < free[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// fitsGPUSlice 只要节点上有一块 GPU 剩余的切片够用就行，切片不能跨 GPU
+func fitsGPUSlice(pod *v1.Pod, node v1.Node) (bool, string) {
+	request := common.PodRequest(pod, Slices.Resource)
+	if request == 0 {
+		return true, ""
+	}
+	free, err := Slices.free(&node)
+	if err != nil {
+		return false, err.Error()
+	}
+	if len(free) == 0 {
+		return false, fmt.Sprintf("node has no GPU to slice, annotation %s not set", GPUSlicesAnnotation)
+	}
+	if pickDevice(free, request) == -1 {
+		return false, fmt.Sprintf("no single GPU has %d %s free, free by GPU %v", request, Slices.Resource, free)
+	}
+	return true, ""
+}
+
+// assume allocates a slice of the node to the pod and returns the GPU, until the pod cache
+// shows the pod bound.
+func (s *GPUSlices) assume(pod *v1.Pod, node *v1.Node) (int, error) {
+	// 两个 Bind 同时算 free 的话会分到同一块 GPU 上
+	s.assumeMu.Lock()
+	defer s.assumeMu.Unlock()
+
+	request := common.PodRequest(pod, s.Resource)
+	free, err := s.free(node)
+	if err != nil {
+		return -1, err
+	}
+	device := pickDevice(free, request)
+	if device == -1 {
+		return -1, fmt.Errorf("no single GPU of node %s has %d %s free", node.Name, request, s.Resource)
+	}
+	s.assumed.assume(pod, node.Name, sliceAllocation{device: device, amount: request})
+	return device, nil
+}
+
+// forget drops the slice assumed for the pod when its binding fails.
+func (s *GPUSlices) forget(uid types.UID) {
+	s.assumed.forget(uid)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+const gpuMem v1.ResourceName = "toys.io/gpu-mem"
+
+func slicePod(name, nodeName string, mem int64, device string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Containers: []v1.Container{{
+				Name: "c",
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{gpuMem: *resource.NewQuantity(mem, resource.DecimalSI)},
+				},
+			}},
+		},
+	}
+	if device != "" {
+		pod.Annotations = map[string]string{GPUDeviceAnnotation: device}
+	}
+	return pod
+}
+
+func sliceNode(name, slices string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{Label: "tesla-t4"},
+		Annotations: map[string]string{GPUSlicesAnnotation: slices},
+	}}
+}
+
+func TestGPUSlices(t *testing.T) {
+	n1, n2, n3 := sliceNode("n1", "8000,8000"), sliceNode("n2", ""), sliceNode("n3", "16000")
+	// n1 的两块 GPU 分别剩 2000 和 5000
+	client := fake.NewSimpleClientset(n1, n2, n3,
+		slicePod("running-0", "n1", 6000, "0"),
+		slicePod("running-1", "n1", 3000, "1"),
+		slicePod("pending-a", "", 4000, ""),
+		slicePod("pending-b", "", 4000, ""),
+		slicePod("pending-c", "", 6000, ""),
+	)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	pods, err := common.NewPodCache(client, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSlices := Slices
+	Slices = NewGPUSlices(gpuMem)
+	t.Cleanup(func() { Slices = oldSlices })
+	setManaged(t, []ManagedResource{{Name: gpuMem, IgnoredByScheduler: true}}, pods)
+	ex := &Extender{ClientSet: client}
+
+	args := extenderv1.ExtenderArgs{
+		Pod:   slicePod("pending-c", "", 6000, ""),
+		Nodes: &v1.NodeList{Items: []v1.Node{*n1, *n2, *n3}},
+	}
+	res, err := ex.Filter(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"n3"}; !reflect.DeepEqual(*res.NodeNames, want) {
+		t.Errorf("Filter returned %v for 6000 MiB, want %v", *res.NodeNames, want)
+	}
+
+	bind := func(name string) (string, error) {
+		_, err := ex.Bind(extenderv1.ExtenderBindingArgs{PodName: name, PodNamespace: "default", PodUID: types.UID(name), Node: "n1"})
+		if err != nil {
+			return "", err
+		}
+		pod, err := client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod.Annotations[GPUDeviceAnnotation], nil
+	}
+	// 只有 GPU 1 放得下 4000
+	device, err := bind("pending-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if device != "1" {
+		t.Errorf("pending-a got GPU %q, want 1", device)
+	}
+	// pending-a 还没出现在 pod cache 里，GPU 1 也只剩 1000 了
+	if _, err := bind("pending-b"); err == nil {
+		t.Error("pending-b bound to n1 without a GPU with 4000 free")
+	}
+}
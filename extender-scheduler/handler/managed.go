@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"fmt"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+)
+
+// ManagedResource is an extended resource the extender is called for, the same as
+// managedResources of the extender in the scheduler config.
+type ManagedResource struct {
+	Name v1.ResourceName
+	// IgnoredByScheduler means the scheduler doesn't check the resource fits, Filter and
+	// FilterOnlyOne do it instead.
+	IgnoredByScheduler bool
+}
+
+// ManagedResources are the resources the extender handles pods for, all pods when empty.
+var ManagedResources []ManagedResource
+
+// Pods counts the ignoredByScheduler resources allocated on each node, only the allocatable
+// of the nodes is checked when nil.
+var Pods *common.PodCache
+
+// isManaged returns true when the extender handles the pod: no ManagedResources, or the pod
+// requests or limits one of them, like the scheduler decides whether to call the extender.
+func isManaged(pod *v1.Pod) bool {
+	if len(ManagedResources) == 0 {
+		return true
+	}
+	if pod == nil {
+		return false
+	}
+	for _, r := range ManagedResources {
+		if common.PodRequest(pod, r.Name) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// passThrough is the filter result for the pods the extender doesn't handle: all nodes pass.
+func passThrough(args extenderv1.ExtenderArgs) *extenderv1.ExtenderFilterResult {
+	if args.Nodes == nil {
+		return &extenderv1.ExtenderFilterResult{NodeNames: args.NodeNames}
+	}
+	nodeNames := make([]string, 0, len(args.Nodes.Items))
+	for _, node := range args.Nodes.Items {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	return &extenderv1.ExtenderFilterResult{Nodes: args.Nodes, NodeNames: &nodeNames}
+}
+
+// activePredicates are the predicates checked for the pod: fitsIgnoredResources only matters
+// when some managed resource is ignored by the scheduler, fitsGPUSlice when GPU slicing is on,
+// fitsGPUTopology when topology aware placement is on.
+func activePredicates() []predicate {
+	active := predicates[:len(predicates):len(predicates)]
+	for _, r := range ManagedResources {
+		if r.IgnoredByScheduler && (Slices == nil || r.Name != Slices.Resource) {
+			active = append(active, predicate{name: "FitsIgnoredResources", fit: fitsIgnoredResources})
+			break
+		}
+	}
+	if Slices != nil {
+		active = append(active, predicate{name: "FitsGPUSlice", fit: fitsGPUSlice})
+	}
+	if Topology != nil {
+		active = append(active, predicate{name: "FitsGPUTopology", fit: fitsGPUTopology})
+	}
+	return active
+}
+
+// fitsIgnoredResources 检查 scheduler 不管的资源在节点上还够不够
+// 注意：scheduler assume 了但还没绑定的 Pod 在 Pods 里看不到，同一批调度的 Pod 可能会超用
+func fitsIgnoredResources(pod *v1.Pod, node v1.Node) (bool, string) {
+	for _, r := range ManagedResources {
+		// GPU 切片按单块 GPU 统计，见 fitsGPUSlice
+		if !r.IgnoredByScheduler || (Slices != nil && r.Name == Slices.Resource) {
+			continue
+		}
+		request := common.PodRequest(pod, r.Name)
+		if request == 0 {
+			continue
+		}
+		allocatable := node.Status.Allocatable[r.Name]
+		free := allocatable.Value() - Pods.Allocated(node.Name, r.Name)
+		if request > free {
+			return false, fmt.Sprintf("insufficient %s: requested %d, free %d", r.Name, request, free)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const gpu v1.ResourceName = "nvidia.com/gpu"
+
+// setManaged sets ManagedResources and Pods for the test.
+func setManaged(t *testing.T, resources []ManagedResource, pods *common.PodCache) {
+	t.Helper()
+	oldResources, oldPods := ManagedResources, Pods
+	ManagedResources, Pods = resources, pods
+	t.Cleanup(func() { ManagedResources, Pods = oldResources, oldPods })
+}
+
+func withGPUs(pod *v1.Pod, n int64) *v1.Pod {
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{
+		Name: "c",
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{gpu: *resource.NewQuantity(n, resource.DecimalSI)},
+		},
+	})
+	return pod
+}
+
+func TestNotManagedPodPasses(t *testing.T) {
+	setManaged(t, []ManagedResource{{Name: gpu}}, nil)
+	args := makeArgs()
+
+	for name, filter := range map[string]func() ([]string, error){
+		"Filter": func() ([]string, error) {
+			res, err := Ex.Filter(makeArgsWith(args))
+			return *res.NodeNames, err
+		},
+		"FilterOnlyOne": func() ([]string, error) {
+			res, err := Ex.FilterOnlyOne(makeArgsWith(args))
+			return *res.NodeNames, err
+		},
+	} {
+		got, err := filter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"n1", "n2", "n3"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s returned %v for a pod without GPUs, want all nodes %v", name, got, want)
+		}
+	}
+
+	priorities, err := Ex.Prioritize(makeArgsWith(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*priorities) != 0 {
+		t.Errorf("Prioritize scored a pod without GPUs: %v", *priorities)
+	}
+
+	e, err := Ex.Explain(makeArgsWith(args), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.NotManaged {
+		t.Errorf("Explain doesn't tell the pod is not managed: %+v", e)
+	}
+
+	// 请求了 GPU 的 Pod 照常过滤
+	args.Pod = withGPUs(args.Pod, 1)
+	res, err := Ex.Filter(makeArgsWith(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"n1", "n3"}; !reflect.DeepEqual(*res.NodeNames, want) {
+		t.Errorf("Filter returned %v for a pod with GPUs, want %v", *res.NodeNames, want)
+	}
+}
+
+func TestFitsIgnoredResources(t *testing.T) {
+	running := withGPUs(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"}, Spec: v1.PodSpec{NodeName: "n1"}}, 2)
+	finished := withGPUs(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "finished", Namespace: "default"}, Spec: v1.PodSpec{NodeName: "n3"}}, 4)
+	finished.Status.Phase = v1.PodSucceeded
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	pods, err := common.NewPodCache(fake.NewSimpleClientset(running, finished), stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setManaged(t, []ManagedResource{{Name: gpu, IgnoredByScheduler: true}}, pods)
+
+	args := makeArgs()
+	for i, gpus := range []int64{4, 0, 4} {
+		args.Nodes.Items[i].Status.Allocatable = v1.ResourceList{gpu: *resource.NewQuantity(gpus, resource.DecimalSI)}
+	}
+	args.Pod = withGPUs(args.Pod, 3)
+
+	// n1 上只剩 2 个 GPU；n3 上的 Pod 已经结束，4 个都能用
+	res, err := Ex.Filter(makeArgsWith(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"n3"}; !reflect.DeepEqual(*res.NodeNames, want) {
+		t.Errorf("Filter returned %v, want %v", *res.NodeNames, want)
+	}
+
+	e, err := Ex.Explain(makeArgsWith(args), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ne := range e.Nodes {
+		if ne.Name != "n1" {
+			continue
+		}
+		want := PredicateResult{Name: "FitsIgnoredResources", Reason: "insufficient nvidia.com/gpu: requested 3, free 2"}
+		if got := ne.Predicates[len(ne.Predicates)-1]; got != want {
+			t.Errorf("explained n1 %+v, want %+v", got, want)
+		}
+	}
+}
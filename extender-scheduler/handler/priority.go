@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 	extenderv1 "k8s.io/kube-scheduler/extender/v1"
 )
@@ -15,33 +18,69 @@ var DataDict = map[string]int{
 // 想要完全控制调度结果，只能在 Filter 接口中实现，过滤掉不满足条件的节点，并对剩余节点进行打分，最终 Filter 接口只返回得分最高的那个节点
 func (ex *Extender) Prioritize(args extenderv1.ExtenderArgs) (*extenderv1.HostPriorityList, error) {
 	var result extenderv1.HostPriorityList
+	// 不归 extender 管的 Pod 不打分，不影响 scheduler 的选择
+	if !isManaged(args.Pod) {
+		return &result, nil
+	}
+	if args.Nodes == nil {
+		return nil, errors.New("prioritize needs ExtenderArgs.Nodes, nodeCacheCapable extenders are not supported")
+	}
 	for _, node := range args.Nodes.Items {
 		klog.Info("...............", node.Name)
 
-		if _, ok := node.Labels["test-label"]; ok {
-			klog.Info("found label,:::", node.Name)
-			result = append(result, extenderv1.HostPriority{
-				Host:  node.Name,
-				Score: 100000,
-			})
-			continue
+		var score int64
+		scored := false
+		for _, sc := range activeScorers() {
+			if s, ok := sc.score(args.Pod, node); ok {
+				score += s
+				scored = true
+			}
 		}
-
-		// 获取 Node 上的 Label 作为分数
-		priorityStr, ok := node.Labels[Label]
-		if !ok {
+		if !scored {
 			klog.Errorf("node %q does not have label %s", node.Name, Label)
 			continue
 		}
-
-		priority := DataDict[priorityStr]
-
 		result = append(result, extenderv1.HostPriority{
 			Host:  node.Name,
-			Score: int64(priority),
+			Score: score,
 		})
 	}
 
 	klog.Info("res info:::", result)
 	return &result, nil
 }
+
+// scorer gives a node a part of its Prioritize score, false when it doesn't score the node.
+// A node no scorer scores is left out of the Prioritize result.
+type scorer struct {
+	name  string
+	score func(pod *v1.Pod, node v1.Node) (int64, bool)
+}
+
+// scorers are summed by Prioritize and listed by Explain, see activeScorers for the ones
+// depending on the flags.
+var scorers = []scorer{
+	{name: "Prioritize", score: func(_ *v1.Pod, node v1.Node) (int64, bool) { return prioritizeScore(node) }},
+}
+
+// activeScorers adds gpuTopologyScore when topology aware placement is on.
+func activeScorers() []scorer {
+	if Topology != nil {
+		return append(scorers[:len(scorers):len(scorers)], scorer{name: "GPUTopology", score: gpuTopologyScore})
+	}
+	return scorers
+}
+
+// prioritizeScore returns the score Prioritize gives the node, false when the node is left out of the list.
+func prioritizeScore(node v1.Node) (int64, bool) {
+	if _, ok := node.Labels["test-label"]; ok {
+		return 100000, true
+	}
+
+	// 获取 Node 上的 Label 作为分数
+	priorityStr, ok := node.Labels[Label]
+	if !ok {
+		return 0, false
+	}
+	return int64(DataDict[priorityStr]), true
+}
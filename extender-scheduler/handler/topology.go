@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// GPUTopologyAnnotation on a node is the NodeGPUTopology JSON published by the node agent.
+	GPUTopologyAnnotation = "toys.io/gpu-topology"
+	// GPUDevicesAnnotation is set on the pod by Bind to the comma separated indexes of the GPUs
+	// chosen for it, for the device plugin to expose those GPUs to the pod.
+	GPUDevicesAnnotation = "toys.io/gpu-devices"
+)
+
+// NodeGPUTopology is how the GPUs of a node are connected.
+type NodeGPUTopology struct {
+	GPUs []GPUDevice `json:"gpus"`
+}
+
+// GPUDevice is a GPU and the interconnects it shares with the other GPUs, empty when unknown.
+type GPUDevice struct {
+	Index       int    `json:"index"`
+	NVLinkGroup string `json:"nvlinkGroup,omitempty"`
+	PCIeSwitch  string `json:"pcieSwitch,omitempty"`
+	NUMA        *int   `json:"numa,omitempty"`
+}
+
+// Interconnect is the slowest link between the GPUs of a set, the higher the better.
+type Interconnect int
+
+const (
+	// InterconnectSystem means the GPUs talk across NUMA nodes.
+	InterconnectSystem Interconnect = iota
+	InterconnectNUMA
+	InterconnectPCIeSwitch
+	InterconnectNVLink
+)
+
+func (i Interconnect) String() string {
+	return [...]string{"System", "NUMA", "PCIeSwitch", "NVLink"}[i]
+}
+
+// interconnectScores are the Prioritize scores of the best interconnect a node can give the pod.
+var interconnectScores = map[Interconnect]int64{
+	InterconnectNVLink:     10,
+	InterconnectPCIeSwitch: 7,
+	InterconnectNUMA:       4,
+	InterconnectSystem:     1,
+}
+
+// Topology places the pods requesting several GPUs of Resource within one high bandwidth group,
+// nil when topology aware placement is off.
+var Topology *GPUTopology
+
+// GPUTopology tracks the GPUs of Resource allocated to the pods on each node.
+type GPUTopology struct {
+	Resource v1.ResourceName
+	// MinInterconnect is the interconnect a node must give the pod to pass the filter.
+	MinInterconnect Interconnect
+
+	// assumeMu serializes assume.
+	assumeMu sync.Mutex
+	// assumed are the GPUs Bind chose for the pods the pod cache doesn't show bound yet.
+	assumed *assumeCache[[]int]
+}
+
+// NewGPUTopology returns the topology tracker of the resource, a node passes the filter when
+// it can give the pod GPUs on one NVLink group or PCIe switch.
+func NewGPUTopology(resource v1.ResourceName) *GPUTopology {
+	return &GPUTopology{
+		Resource:        resource,
+		MinInterconnect: InterconnectPCIeSwitch,
+		assumed:         newAssumeCache[[]int](),
+	}
+}
+
+// nodeTopology parses GPUTopologyAnnotation of the node, nil when not set.
+func nodeTopology(node *v1.Node) (*NodeGPUTopology, error) {
+	value, ok := node.Annotations[GPUTopologyAnnotation]
+	if !ok || value == "" {
+		return nil, nil
+	}
+	topology := &NodeGPUTopology{}
+	if err := json.Unmarshal([]byte(value), topology); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %w", GPUTopologyAnnotation, err)
+	}
+	return topology, nil
+}
+
+// freeDevices returns the GPUs of the node not allocated to the pods on the node or assumed.
+// 没有 GPUDevicesAnnotation 的 Pod 不知道用了哪几块，这里不扣；总数还是由 scheduler 按 Resource 检查
+func (t *GPUTopology) freeDevices(node *v1.Node, topology *NodeGPUTopology) []GPUDevice {
+	used := make(map[int]bool)
+	for _, devices := range t.assumed.onNode(node.Name) {
+		for _, d := range devices {
+			used[d] = true
+		}
+	}
+	for _, pod := range Pods.PodsOnNode(node.Name) {
+		for _, d := range podDevices(pod) {
+			used[d] = true
+		}
+	}
+
+	var free []GPUDevice
+	for _, gpu := range topology.GPUs {
+		if !used[gpu.Index] {
+			free = append(free, gpu)
+		}
+	}
+	return free
+}
+
+// podDevices returns GPUDevicesAnnotation of the pod.
+func podDevices(pod *v1.Pod) []int {
+	var devices []int
+	for _, item := range strings.Split(pod.Annotations[GPUDevicesAnnotation], ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(item)); err == nil {
+			devices = append(devices, d)
+		}
+	}
+	return devices
+}
+
+// pickDevices returns count GPUs of free with the best interconnect. Within an interconnect
+// the smallest group that fits is used, the larger groups are left to larger requests.
+func pickDevices(free []GPUDevice, count int) ([]int, Interconnect, bool) {
+	if count > len(free) {
+		return nil, InterconnectSystem, false
+	}
+	levels := []struct {
+		interconnect Interconnect
+		key          func(GPUDevice) string
+	}{
+		{InterconnectNVLink, func(d GPUDevice) string { return d.NVLinkGroup }},
+		{InterconnectPCIeSwitch, func(d GPUDevice) string { return d.PCIeSwitch }},
+		{InterconnectNUMA, func(d GPUDevice) string {
+			if d.NUMA == nil {
+				return ""
+			}
+			return strconv.Itoa(*d.NUMA)
+		}},
+	}
+	for _, level := range levels {
+		groups := make(map[string][]GPUDevice)
+		var keys []string
+		for _, d := range free {
+			key := level.key(d)
+			if key == "" {
+				continue
+			}
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], d)
+		}
+		best := ""
+		for _, key := range keys {
+			if len(groups[key]) >= count && (best == "" || len(groups[key]) < len(groups[best])) {
+				best = key
+			}
+		}
+		if best != "" {
+			return indexes(groups[best][:count]), level.interconnect, true
+		}
+	}
+	return indexes(free[:count]), InterconnectSystem, true
+}
+
+func indexes(devices []GPUDevice) []int {
+	var idx []int
+	for _, d := range devices {
+		idx = append(idx, d.Index)
+	}
+	sort.Ints(idx)
+	return idx
+}
+
+// placement returns the GPUs the pod would get on the node and their interconnect, the reason
+// when the node can't give the pod that many GPUs.
+func (t *GPUTopology) placement(pod *v1.Pod, node *v1.Node) ([]int, Interconnect, string) {
+	count := int(common.PodRequest(pod, t.Resource))
+	topology, err := nodeTopology(node)
+	if err != nil {
+		return nil, InterconnectSystem, err.Error()
+	}
+	if topology == nil {
+		return nil, InterconnectSystem, fmt.Sprintf("node has no GPU topology, annotation %s not set", GPUTopologyAnnotation)
+	}
+	free := t.freeDevices(node, topology)
+	devices, interconnect, ok := pickDevices(free, count)
+	if !ok {
+		return nil, InterconnectSystem, fmt.Sprintf("requested %d GPUs, %d free", count, len(free))
+	}
+	return devices, interconnect, ""
+}
+
+// requestsGPUs returns true when topology matters for the pod: it requests more than one GPU.
+func (t *GPUTopology) requestsGPUs(pod *v1.Pod) bool {
+	return common.PodRequest(pod, t.Resource) > 1
+}
+
+// fitsGPUTopology 多卡的 Pod 要能在一个 NVLink 组或者 PCIe switch 下拿到足够的 GPU
+func fitsGPUTopology(pod *v1.Pod, node v1.Node) (bool, string) {
+	if !Topology.requestsGPUs(pod) {
+		return true, ""
+	}
+	_, interconnect, reason := Topology.placement(pod, &node)
+	if reason != "" {
+		return false, reason
+	}
+	if interconnect < Topology.MinInterconnect {
+		return false, fmt.Sprintf("the free GPUs are only connected by %s, %s required", interconnect, Topology.MinInterconnect)
+	}
+	return true, ""
+}
+
+// gpuTopologyScore scores the node by the best interconnect it can give the pod.
+func gpuTopologyScore(pod *v1.Pod, node v1.Node) (int64, bool) {
+	if !Topology.requestsGPUs(pod) {
+		return 0, false
+	}
+	_, interconnect, reason := Topology.placement(pod, &node)
+	if reason != "" {
+		return 0, false
+	}
+	return interconnectScores[interconnect], true
+}
+
+// assume chooses the GPUs of the node for the pod, until the pod cache shows the pod bound.
+func (t *GPUTopology) assume(pod *v1.Pod, node *v1.Node) ([]int, error) {
+	t.assumeMu.Lock()
+	defer t.assumeMu.Unlock()
+
+	devices, _, reason := t.placement(pod, node)
+	if reason != "" {
+		return nil, fmt.Errorf("node %s: %s", node.Name, reason)
+	}
+	t.assumed.assume(pod, node.Name, devices)
+	return devices, nil
+}
+
+// forget drops the GPUs assumed for the pod when its binding fails.
+func (t *GPUTopology) forget(uid types.UID) {
+	t.assumed.forget(uid)
+}
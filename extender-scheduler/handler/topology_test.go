@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"extender-scheduler/common"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/utils/ptr"
+)
+
+// dgxTopology is 8 GPUs: NVLink groups of 4, a PCIe switch for every 2 and a NUMA node for every 4.
+func dgxTopology() NodeGPUTopology {
+	var topology NodeGPUTopology
+	for i := 0; i < 8; i++ {
+		topology.GPUs = append(topology.GPUs, GPUDevice{
+			Index:       i,
+			NVLinkGroup: []string{"nvl0", "nvl1"}[i/4],
+			PCIeSwitch:  []string{"sw0", "sw1", "sw2", "sw3"}[i/2],
+			NUMA:        ptr.To(i / 4),
+		})
+	}
+	return topology
+}
+
+func TestPickDevices(t *testing.T) {
+	pcieOnly := dgxTopology()
+	for i := range pcieOnly.GPUs {
+		pcieOnly.GPUs[i].NVLinkGroup = ""
+	}
+	tests := []struct {
+		name             string
+		topology         NodeGPUTopology
+		free             []int
+		count            int
+		wantDevices      []int
+		wantInterconnect Interconnect
+		wantOK           bool
+	}{
+		{
+			name:             "one NVLink group",
+			topology:         dgxTopology(),
+			free:             []int{0, 1, 2, 3, 4, 5, 6, 7},
+			count:            4,
+			wantDevices:      []int{0, 1, 2, 3},
+			wantInterconnect: InterconnectNVLink,
+			wantOK:           true,
+		},
+		{
+			// nvl0 只剩 2 块，正好给 2 卡的 Pod，nvl1 留给更大的请求
+			name:             "smallest group that fits",
+			topology:         dgxTopology(),
+			free:             []int{2, 3, 4, 5, 6},
+			count:            2,
+			wantDevices:      []int{2, 3},
+			wantInterconnect: InterconnectNVLink,
+			wantOK:           true,
+		},
+		{
+			name:             "PCIe switch",
+			topology:         pcieOnly,
+			free:             []int{1, 4, 5},
+			count:            2,
+			wantDevices:      []int{4, 5},
+			wantInterconnect: InterconnectPCIeSwitch,
+			wantOK:           true,
+		},
+		{
+			name:             "across NUMA nodes",
+			topology:         dgxTopology(),
+			free:             []int{3, 4},
+			count:            2,
+			wantDevices:      []int{3, 4},
+			wantInterconnect: InterconnectSystem,
+			wantOK:           true,
+		},
+		{
+			name:     "not enough GPUs",
+			topology: dgxTopology(),
+			free:     []int{0},
+			count:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var free []GPUDevice
+			for _, i := range tt.free {
+				free = append(free, tt.topology.GPUs[i])
+			}
+			devices, interconnect, ok := pickDevices(free, tt.count)
+			if ok != tt.wantOK || !reflect.DeepEqual(devices, tt.wantDevices) || (ok && interconnect != tt.wantInterconnect) {
+				t.Errorf("got %v %s %v, want %v %s %v", devices, interconnect, ok, tt.wantDevices, tt.wantInterconnect, tt.wantOK)
+			}
+		})
+	}
+}
+
+func topologyNode(t *testing.T, name string, topology NodeGPUTopology) *v1.Node {
+	data, err := json.Marshal(topology)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Labels:      map[string]string{Label: "ampere-a100"},
+		Annotations: map[string]string{GPUTopologyAnnotation: string(data)},
+	}}
+}
+
+func gpuPod(name, nodeName string, count int64, devices string) *v1.Pod {
+	pod := withGPUs(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)}, Spec: v1.PodSpec{NodeName: nodeName}}, count)
+	if devices != "" {
+		pod.Annotations = map[string]string{GPUDevicesAnnotation: devices}
+	}
+	return pod
+}
+
+func TestGPUTopology(t *testing.T) {
+	// n1 没有 NVLink，每个 PCIe switch 各用掉一块，剩下的 1、3、5、7 只能凑在一个 NUMA 里
+	fragmented := dgxTopology()
+	for i := range fragmented.GPUs {
+		fragmented.GPUs[i].NVLinkGroup = ""
+	}
+	n1, n2 := topologyNode(t, "n1", fragmented), topologyNode(t, "n2", dgxTopology())
+	// n2 剩下 nvl0 的 3 和 nvl1 的 5、6、7
+	client := fake.NewSimpleClientset(n1, n2,
+		gpuPod("running-1", "n1", 4, "0,2,4,6"),
+		gpuPod("running-2", "n2", 4, "0,1,2,4"),
+		gpuPod("pending-a", "", 2, ""),
+		gpuPod("pending-b", "", 2, ""),
+	)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	pods, err := common.NewPodCache(client, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldTopology := Topology
+	Topology = NewGPUTopology(gpu)
+	t.Cleanup(func() { Topology = oldTopology })
+	setManaged(t, []ManagedResource{{Name: gpu}}, pods)
+	ex := &Extender{ClientSet: client}
+
+	args := extenderv1.ExtenderArgs{
+		Pod:   gpuPod("pending-a", "", 2, ""),
+		Nodes: &v1.NodeList{Items: []v1.Node{*n1, *n2}},
+	}
+	res, err := ex.Filter(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"n2"}; !reflect.DeepEqual(*res.NodeNames, want) {
+		t.Errorf("Filter returned %v, want %v", *res.NodeNames, want)
+	}
+	priorities, err := ex.Prioritize(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := extenderv1.HostPriorityList{
+		{Host: "n1", Score: int64(DataDict["ampere-a100"]) + interconnectScores[InterconnectNUMA]},
+		{Host: "n2", Score: int64(DataDict["ampere-a100"]) + interconnectScores[InterconnectNVLink]},
+	}
+	if !reflect.DeepEqual(*priorities, want) {
+		t.Errorf("Prioritize returned %v, want %v", *priorities, want)
+	}
+
+	bind := func(name string) string {
+		if _, err := ex.Bind(extenderv1.ExtenderBindingArgs{PodName: name, PodNamespace: "default", PodUID: types.UID(name), Node: "n2"}); err != nil {
+			t.Fatal(err)
+		}
+		pod, err := client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pod.Annotations[GPUDevicesAnnotation]
+	}
+	if got := bind("pending-a"); got != "5,6" {
+		t.Errorf("pending-a bound to GPUs %q, want 5,6", got)
+	}
+	// pending-a 分到的 GPU 不能再分出去
+	if got := bind("pending-b"); got != "3,7" {
+		t.Errorf("pending-b bound to GPUs %q, want 3,7", got)
+	}
+}
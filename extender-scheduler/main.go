@@ -1,7 +1,16 @@
 package main
 
 import (
+	"flag"
+	"os"
+
+	"extender-scheduler/capture"
+	"extender-scheduler/cmd"
+	"extender-scheduler/common"
+	"extender-scheduler/handler"
 	"extender-scheduler/routers"
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
 )
 
 // 如果不实现nodeCacheCapable 就不用初始化这个client-go ClientSet
@@ -11,7 +20,56 @@ func init() {
 }
 
 func main() {
-	r := routers.InitMgrRouter()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			os.Exit(cmd.Replay(os.Args[2:]))
+		case "explain":
+			os.Exit(cmd.Explain(os.Args[2:]))
+		}
+	}
+
+	captureDir := flag.String("capture-dir", "", "directory the requests and responses are captured to, no capture if empty")
+	sampleRate := flag.Float64("capture-sample-rate", 1, "share of the requests captured, from 0 to 1")
+	maxRecordBytes := flag.Int("capture-max-record-bytes", 4<<20, "requests whose args and response are larger are not captured, 0 means no limit")
+	maxFileBytes := flag.Int64("capture-max-file-bytes", 64<<20, "size a capture file is rotated at")
+	maxFiles := flag.Int("capture-max-files", 10, "number of rotated capture files kept")
+	managed := flag.String("managed-resources", "", "comma separated extended resources the extender handles pods for, all pods if empty; same as managedResources in the scheduler config")
+	ignored := flag.String("ignored-by-scheduler-resources", "", "comma separated managed resources with ignoredByScheduler, the extender checks they fit using a pod informer")
+	gpuSlice := flag.String("gpu-slice-resource", "", "virtual resource of GPU slices, e.g. toys.io/gpu-mem; the capacity of each GPU is read from the node annotation "+handler.GPUSlicesAnnotation+
+		" and the extender binds the pods requesting it, off if empty")
+	gpuTopology := flag.String("gpu-topology-resource", "", "GPU resource, e.g. nvidia.com/gpu, whose multi-GPU pods are placed within one NVLink group or PCIe switch by the node annotation "+handler.GPUTopologyAnnotation+
+		" and bound by the extender, off if empty")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	cmd.ConfigureResources(*managed, *ignored, *gpuSlice, *gpuTopology)
+	if cmd.NeedsPodCache() {
+		// scheduler 不检查的资源、分给每块 GPU 的量，extender 都要自己统计
+		handler.NewExtender()
+		pods, err := common.NewPodCache(handler.Ex.ClientSet, make(chan struct{}))
+		if err != nil {
+			klog.Fatalf("start pod cache: %v", err)
+		}
+		handler.Pods = pods
+	}
+
+	var middlewares []gin.HandlerFunc
+	if *captureDir != "" {
+		rec, err := capture.NewRecorder(capture.Options{
+			Dir:            *captureDir,
+			SampleRate:     *sampleRate,
+			MaxRecordBytes: *maxRecordBytes,
+			MaxFileBytes:   *maxFileBytes,
+			MaxFiles:       *maxFiles,
+		})
+		if err != nil {
+			klog.Fatalf("create capture recorder: %v", err)
+		}
+		defer rec.Close()
+		middlewares = append(middlewares, rec.Middleware())
+	}
+	r := routers.InitMgrRouter(middlewares...)
 
 	r.Run(":32080")
 }
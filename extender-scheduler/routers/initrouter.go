@@ -2,8 +2,10 @@ package routers
 
 import "github.com/gin-gonic/gin"
 
-func InitMgrRouter() *gin.Engine {
+// InitMgrRouter builds the router of the extender, the middlewares run before every route.
+func InitMgrRouter(middlewares ...gin.HandlerFunc) *gin.Engine {
 	r := gin.New()
+	r.Use(middlewares...)
 
 	MyCustomScheduler(r)
 
@@ -8,6 +8,7 @@ import (
 func MyCustomScheduler(r *gin.Engine) {
 	r.POST("/filter", apis.Filter)
 	r.POST("/prioritize", apis.Prioritize)
-	// r.POST("/bind", apis.Bind)
+	r.POST("/bind", apis.Bind)
 	r.POST("/allinone", apis.AllInOne)
+	r.POST("/explain", apis.Explain)
 }
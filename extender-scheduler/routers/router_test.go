@@ -0,0 +1,86 @@
+package routers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 修改了 extender 的行为后用 go test ./routers -update 重新生成 golden 文件，diff 一起提交 review
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// golden is the recorded response of the extender to a fixture.
+type golden struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// TestExtenderGolden posts each ExtenderArgs fixture in testdata/<endpoint>/ to the router
+// and compares the response with the .golden file next to the fixture.
+func TestExtenderGolden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	srv := httptest.NewServer(InitMgrRouter())
+	defer srv.Close()
+
+	for _, endpoint := range []string{"filter", "prioritize", "allinone", "explain"} {
+		fixtures, err := filepath.Glob(filepath.Join("testdata", endpoint, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fixtures) == 0 {
+			t.Fatalf("no fixtures for /%s", endpoint)
+		}
+		for _, fixture := range fixtures {
+			name := endpoint + "/" + strings.TrimSuffix(filepath.Base(fixture), ".json")
+			t.Run(name, func(t *testing.T) {
+				got := post(t, srv.URL+"/"+endpoint, fixture)
+				goldenFile := strings.TrimSuffix(fixture, ".json") + ".golden"
+				if *update {
+					if err := os.WriteFile(goldenFile, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(goldenFile)
+				if err != nil {
+					t.Fatalf("read golden file, run with -update to create it: %v", err)
+				}
+				if !bytes.Equal(want, got) {
+					t.Errorf("response of %s differs from %s, run with -update if intended\nwant:\n%s\ngot:\n%s", fixture, goldenFile, want, got)
+				}
+			})
+		}
+	}
+}
+
+// post sends the fixture to the url and returns the response in the golden file format.
+func post(t *testing.T, url, fixture string) []byte {
+	t.Helper()
+	req, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.MarshalIndent(golden{Status: resp.StatusCode, Body: body.Bytes()}, "", "  ")
+	if err != nil {
+		t.Fatalf("response is not JSON: %v\n%s", err, body.String())
+	}
+	return append(out, '\n')
+}
@@ -0,0 +1,27 @@
+package scheme
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"test-plugins/apis/config/v1beta2"
+)
+
+var (
+	// Scheme is the runtime.Scheme to which all plugin args types are registered.
+	Scheme = runtime.NewScheme()
+
+	// Codecs provides access to encoding and decoding for the scheme.
+	// Decoding is strict, unknown or duplicate fields in the args are an error.
+	Codecs = serializer.NewCodecFactory(Scheme, serializer.EnableStrict)
+)
+
+func init() {
+	AddToScheme(Scheme)
+}
+
+// AddToScheme builds the plugin args scheme using all known versions.
+func AddToScheme(scheme *runtime.Scheme) {
+	utilruntime.Must(v1beta2.AddToScheme(scheme))
+}
@@ -0,0 +1,96 @@
+package v1beta2
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var (
+	defaultAnnotationKey       = "sticky-nodes"
+	defaultSupportedKinds      = []string{"StatefulSet", "ReplicaSet"}
+	defaultMode                = StickyModeRequired
+	defaultRecordOnBind        = false
+	defaultMissingNodesPolicy  = MissingNodesPolicyWait
+	defaultWaitTimeoutSeconds  = int64(0)
+	defaultTopologyKey         = ""
+	defaultFallbackTopologyKey = v1.LabelTopologyZone
+	defaultUseStickyBindings   = false
+	defaultBindingHistoryLimit = int32(10)
+	defaultMaxReplicasPerNode  = int32(0)
+	defaultUseLocalVolumes     = false
+
+	defaultPodGroupLabel            = "pod-group.scheduling.toys.io/name"
+	defaultMinMemberAnnotation      = "pod-group.scheduling.toys.io/min-member"
+	defaultPermitWaitingTimeSeconds = int64(60)
+)
+
+func addDefaultingFuncs(scheme *runtime.Scheme) error {
+	return RegisterDefaults(scheme)
+}
+
+// SetDefaults_StickyPodArgs sets the default parameters for StickyPod plugin.
+func SetDefaults_StickyPodArgs(obj *StickyPodArgs) {
+	if obj.AnnotationKey == nil {
+		v := defaultAnnotationKey
+		obj.AnnotationKey = &v
+	}
+	if len(obj.SupportedKinds) == 0 {
+		obj.SupportedKinds = append([]string(nil), defaultSupportedKinds...)
+	}
+	if obj.DefaultMode == nil {
+		v := defaultMode
+		obj.DefaultMode = &v
+	}
+	if obj.RecordOnBind == nil {
+		v := defaultRecordOnBind
+		obj.RecordOnBind = &v
+	}
+	if obj.MissingNodesPolicy == nil {
+		v := defaultMissingNodesPolicy
+		obj.MissingNodesPolicy = &v
+	}
+	if obj.WaitTimeoutSeconds == nil {
+		v := defaultWaitTimeoutSeconds
+		obj.WaitTimeoutSeconds = &v
+	}
+	if obj.TopologyKey == nil {
+		v := defaultTopologyKey
+		obj.TopologyKey = &v
+	}
+	if obj.FallbackTopologyKey == nil {
+		v := defaultFallbackTopologyKey
+		obj.FallbackTopologyKey = &v
+	}
+	if obj.UseStickyBindings == nil {
+		v := defaultUseStickyBindings
+		obj.UseStickyBindings = &v
+	}
+	if obj.BindingHistoryLimit == nil {
+		v := defaultBindingHistoryLimit
+		obj.BindingHistoryLimit = &v
+	}
+	if obj.MaxReplicasPerNode == nil {
+		v := defaultMaxReplicasPerNode
+		obj.MaxReplicasPerNode = &v
+	}
+	if obj.UseLocalVolumes == nil {
+		v := defaultUseLocalVolumes
+		obj.UseLocalVolumes = &v
+	}
+}
+
+// SetDefaults_GangArgs sets the default parameters for Gang plugin.
+func SetDefaults_GangArgs(obj *GangArgs) {
+	if obj.PodGroupLabel == nil {
+		v := defaultPodGroupLabel
+		obj.PodGroupLabel = &v
+	}
+	if obj.MinMemberAnnotation == nil {
+		v := defaultMinMemberAnnotation
+		obj.MinMemberAnnotation = &v
+	}
+	if obj.PermitWaitingTimeSeconds == nil {
+		v := defaultPermitWaitingTimeSeconds
+		obj.PermitWaitingTimeSeconds = &v
+	}
+}
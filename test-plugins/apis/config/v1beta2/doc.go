@@ -0,0 +1,7 @@
+// +k8s:deepcopy-gen=package
+// +k8s:defaulter-gen=TypeMeta
+// +groupName=kubescheduler.config.k8s.io
+
+// Package v1beta2 holds the versioned arguments of the plugins in this repo,
+// as they are written in the pluginConfig of a KubeSchedulerConfiguration.
+package v1beta2 // import "test-plugins/apis/config/v1beta2"
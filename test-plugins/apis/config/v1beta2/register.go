@@ -0,0 +1,45 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package, the same as the KubeSchedulerConfiguration
+// the args are embedded in.
+const GroupName = "kubescheduler.config.k8s.io"
+
+// SchemeGroupVersion is group version used to register these objects
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1beta2"}
+
+// ConfigGroupVersion is the version of the KubeSchedulerConfiguration of current schedulers,
+// the args written with its apiVersion decode into the same types.
+var ConfigGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1"}
+
+var (
+	// SchemeBuilder is the scheme builder with scheme init functions to run for this API package
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// localSchemeBuilder extends the SchemeBuilder instance with the defaulting funcs.
+	localSchemeBuilder = &SchemeBuilder
+	// AddToScheme is a global function that registers this API group & version to a scheme
+	AddToScheme = localSchemeBuilder.AddToScheme
+)
+
+func init() {
+	// We only register manually written functions here, addDefaultingFuncs
+	// hooks up the generated defaulters.
+	localSchemeBuilder.Register(addDefaultingFuncs)
+}
+
+// addKnownTypes registers known types to the given scheme
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(SchemeGroupVersion,
+		&StickyPodArgs{},
+		&GangArgs{},
+	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	scheme.AddKnownTypeWithName(ConfigGroupVersion.WithKind("StickyPodArgs"), &StickyPodArgs{})
+	scheme.AddKnownTypeWithName(ConfigGroupVersion.WithKind("GangArgs"), &GangArgs{})
+	return nil
+}
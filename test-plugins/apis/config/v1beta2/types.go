@@ -0,0 +1,95 @@
+package v1beta2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StickyMode selects how strictly a pod sticks to its sticky nodes.
+type StickyMode string
+
+const (
+	// StickyModeRequired only sticky nodes pass Filter.
+	StickyModeRequired StickyMode = "required"
+	// StickyModePreferred every node passes Filter, sticky nodes get the highest score.
+	StickyModePreferred StickyMode = "preferred"
+)
+
+// MissingNodesPolicy is what StickyPod does when none of the sticky nodes is available,
+// i.e. they are deleted, cordoned or NotReady.
+type MissingNodesPolicy string
+
+const (
+	// MissingNodesPolicyWait keeps the pod pending until a sticky node is available again.
+	// With WaitTimeoutSeconds set, the pod goes to any feasible node once it waited that long.
+	MissingNodesPolicyWait MissingNodesPolicy = "Wait"
+	// MissingNodesPolicyAnyNode ignores stickiness and lets the pod go to any feasible node.
+	MissingNodesPolicyAnyNode MissingNodesPolicy = "AnyNode"
+	// MissingNodesPolicySameTopology lets the pod go to the nodes sharing the FallbackTopologyKey
+	// label value (zone, rack...) with the sticky nodes still known to the scheduler.
+	MissingNodesPolicySameTopology MissingNodesPolicy = "SameTopology"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StickyPodArgs holds arguments used to configure the StickyPod plugin.
+type StickyPodArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// AnnotationKey is the annotation on the pod owner listing the sticky nodes, comma separated.
+	// Defaults to "sticky-nodes".
+	AnnotationKey *string `json:"annotationKey,omitempty"`
+	// SupportedKinds are the owner kinds whose annotations are looked up.
+	// Defaults to StatefulSet and ReplicaSet.
+	SupportedKinds []string `json:"supportedKinds,omitempty"`
+	// DefaultMode is used when the owner doesn't set the sticky-mode annotation.
+	// Defaults to "required".
+	DefaultMode *StickyMode `json:"defaultMode,omitempty"`
+	// RecordOnBind annotates the owner with the node its pod was bound to,
+	// when the owner has no sticky nodes yet. Defaults to false.
+	RecordOnBind *bool `json:"recordOnBind,omitempty"`
+	// MissingNodesPolicy is what to do when none of the sticky nodes is available.
+	// Only applies to the required mode. Defaults to "Wait".
+	MissingNodesPolicy *MissingNodesPolicy `json:"missingNodesPolicy,omitempty"`
+	// WaitTimeoutSeconds is how long the Wait policy keeps a pod pending before it goes
+	// to any node, 0 means forever. Defaults to 0.
+	WaitTimeoutSeconds *int64 `json:"waitTimeoutSeconds,omitempty"`
+	// TopologyKey is the node label key (zone, rack...) a pod sticks to by default instead of
+	// exact node names, the owner can override it with the sticky-topology-key annotation.
+	// Defaults to "", i.e. node name stickiness.
+	TopologyKey *string `json:"topologyKey,omitempty"`
+	// FallbackTopologyKey is the node label the SameTopology policy matches the sticky nodes on.
+	// Defaults to "topology.kubernetes.io/zone".
+	FallbackTopologyKey *string `json:"fallbackTopologyKey,omitempty"`
+	// UseStickyBindings reads the stickiness from StickyBinding objects before the owner
+	// annotations and records the placements in them. Needs the StickyBinding CRD installed.
+	// Defaults to false.
+	UseStickyBindings *bool `json:"useStickyBindings,omitempty"`
+	// BindingHistoryLimit is how many placements a StickyBinding keeps in its status.
+	// Defaults to 10.
+	BindingHistoryLimit *int32 `json:"bindingHistoryLimit,omitempty"`
+	// MaxReplicasPerNode is how many pods of the same owner may run on each sticky node, the pods
+	// the scheduler assumed on a node are counted until they are bound. 0 means no limit. Defaults to 0.
+	MaxReplicasPerNode *int32 `json:"maxReplicasPerNode,omitempty"`
+	// UseLocalVolumes makes the nodes of the bound local PVs of the pod its sticky nodes, merged
+	// with the stickiness of the owner when it has some. Defaults to false.
+	UseLocalVolumes *bool `json:"useLocalVolumes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GangArgs holds arguments used to configure the Gang plugin.
+type GangArgs struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// PodGroupLabel is the pod label naming the pod group of the pod, the group is the pods of
+	// the namespace with the same value. Defaults to "pod-group.scheduling.toys.io/name".
+	PodGroupLabel *string `json:"podGroupLabel,omitempty"`
+	// MinMemberAnnotation is the pod annotation with the number of pods of the group that must be
+	// placed together, the pods of a group are expected to agree on it.
+	// Defaults to "pod-group.scheduling.toys.io/min-member".
+	MinMemberAnnotation *string `json:"minMemberAnnotation,omitempty"`
+	// PermitWaitingTimeSeconds is how long the pods of a group wait at Permit for the rest of the
+	// group to be placed, before they are all rejected and release their nodes. At most 900,
+	// the longest the scheduler lets a pod wait. Defaults to 60.
+	PermitWaitingTimeSeconds *int64 `json:"permitWaitingTimeSeconds,omitempty"`
+}
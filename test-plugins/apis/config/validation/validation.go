@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"test-plugins/apis/config/v1beta2"
+)
+
+// SupportedOwnerKinds are the owner kinds StickyPod knows how to read and annotate.
+var SupportedOwnerKinds = sets.NewString("StatefulSet", "ReplicaSet", "DaemonSet", "Job", "ReplicationController")
+
+var (
+	validModes              = sets.NewString(string(v1beta2.StickyModeRequired), string(v1beta2.StickyModePreferred))
+	validMissingNodesPolicy = sets.NewString(string(v1beta2.MissingNodesPolicyWait), string(v1beta2.MissingNodesPolicyAnyNode),
+		string(v1beta2.MissingNodesPolicySameTopology))
+)
+
+// ValidateStickyPodArgs validates that StickyPodArgs are correct, args must be defaulted.
+func ValidateStickyPodArgs(path *field.Path, args *v1beta2.StickyPodArgs) error {
+	var allErrs field.ErrorList
+
+	if args.AnnotationKey == nil || *args.AnnotationKey == "" {
+		allErrs = append(allErrs, field.Required(path.Child("annotationKey"), "must not be empty"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(*args.AnnotationKey) {
+			allErrs = append(allErrs, field.Invalid(path.Child("annotationKey"), *args.AnnotationKey, msg))
+		}
+	}
+
+	if len(args.SupportedKinds) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("supportedKinds"), "must not be empty"))
+	}
+	seen := sets.NewString()
+	for i, kind := range args.SupportedKinds {
+		p := path.Child("supportedKinds").Index(i)
+		if !SupportedOwnerKinds.Has(kind) {
+			allErrs = append(allErrs, field.NotSupported(p, kind, SupportedOwnerKinds.List()))
+		}
+		if seen.Has(kind) {
+			allErrs = append(allErrs, field.Duplicate(p, kind))
+		}
+		seen.Insert(kind)
+	}
+
+	if args.DefaultMode == nil {
+		allErrs = append(allErrs, field.Required(path.Child("defaultMode"), ""))
+	} else if !validModes.Has(string(*args.DefaultMode)) {
+		allErrs = append(allErrs, field.NotSupported(path.Child("defaultMode"), *args.DefaultMode, validModes.List()))
+	}
+
+	if args.MissingNodesPolicy == nil {
+		allErrs = append(allErrs, field.Required(path.Child("missingNodesPolicy"), ""))
+	} else if !validMissingNodesPolicy.Has(string(*args.MissingNodesPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(path.Child("missingNodesPolicy"), *args.MissingNodesPolicy, validMissingNodesPolicy.List()))
+	}
+
+	if args.WaitTimeoutSeconds != nil && *args.WaitTimeoutSeconds < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("waitTimeoutSeconds"), *args.WaitTimeoutSeconds, "must not be negative"))
+	}
+
+	if args.TopologyKey != nil && *args.TopologyKey != "" {
+		for _, msg := range validation.IsQualifiedName(*args.TopologyKey) {
+			allErrs = append(allErrs, field.Invalid(path.Child("topologyKey"), *args.TopologyKey, msg))
+		}
+	}
+
+	if args.FallbackTopologyKey == nil || *args.FallbackTopologyKey == "" {
+		allErrs = append(allErrs, field.Required(path.Child("fallbackTopologyKey"), "must not be empty"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(*args.FallbackTopologyKey) {
+			allErrs = append(allErrs, field.Invalid(path.Child("fallbackTopologyKey"), *args.FallbackTopologyKey, msg))
+		}
+	}
+
+	if args.BindingHistoryLimit != nil && *args.BindingHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("bindingHistoryLimit"), *args.BindingHistoryLimit, "must not be negative"))
+	}
+
+	if args.MaxReplicasPerNode != nil && *args.MaxReplicasPerNode < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxReplicasPerNode"), *args.MaxReplicasPerNode, "must not be negative"))
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// maxPermitWaitingTimeSeconds is the longest the scheduler lets a pod wait at Permit.
+const maxPermitWaitingTimeSeconds = 15 * 60
+
+// ValidateGangArgs validates that GangArgs are correct, args must be defaulted.
+func ValidateGangArgs(path *field.Path, args *v1beta2.GangArgs) error {
+	var allErrs field.ErrorList
+
+	if args.PodGroupLabel == nil || *args.PodGroupLabel == "" {
+		allErrs = append(allErrs, field.Required(path.Child("podGroupLabel"), "must not be empty"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(*args.PodGroupLabel) {
+			allErrs = append(allErrs, field.Invalid(path.Child("podGroupLabel"), *args.PodGroupLabel, msg))
+		}
+	}
+
+	if args.MinMemberAnnotation == nil || *args.MinMemberAnnotation == "" {
+		allErrs = append(allErrs, field.Required(path.Child("minMemberAnnotation"), "must not be empty"))
+	} else {
+		for _, msg := range validation.IsQualifiedName(*args.MinMemberAnnotation) {
+			allErrs = append(allErrs, field.Invalid(path.Child("minMemberAnnotation"), *args.MinMemberAnnotation, msg))
+		}
+	}
+
+	if args.PermitWaitingTimeSeconds == nil || *args.PermitWaitingTimeSeconds <= 0 || *args.PermitWaitingTimeSeconds > maxPermitWaitingTimeSeconds {
+		var v interface{}
+		if args.PermitWaitingTimeSeconds != nil {
+			v = *args.PermitWaitingTimeSeconds
+		}
+		allErrs = append(allErrs, field.Invalid(path.Child("permitWaitingTimeSeconds"), v, fmt.Sprintf("must be between 1 and %d", maxPermitWaitingTimeSeconds)))
+	}
+
+	return allErrs.ToAggregate()
+}
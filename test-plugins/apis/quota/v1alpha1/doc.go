@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=quota.scheduling.toys.io
+
+// Package v1alpha1 is the v1alpha1 version of the ElasticQuota API, the guaranteed and the
+// maximum capacity of the pods of a namespace.
+package v1alpha1 // import "test-plugins/apis/quota/v1alpha1"
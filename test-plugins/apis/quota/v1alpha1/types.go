@@ -0,0 +1,43 @@
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticQuota is the capacity the pods of its namespace, a team, may use. The team is always
+// given Min and may borrow the unused Min of the other teams up to Max; the borrowed capacity
+// is reclaimed by preemption when its owner needs it. Only Min is lent: the cluster capacity
+// not covered by the Min of any team is never borrowed, so the sum of Min is the most the teams
+// use together. There should be one per namespace.
+type ElasticQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticQuotaSpec `json:"spec"`
+}
+
+// ElasticQuotaSpec is the guaranteed and the maximum capacity of the namespace, by the resource
+// requests of its pods, e.g. cpu, memory and nvidia.com/gpu.
+type ElasticQuotaSpec struct {
+	// Min is the capacity guaranteed to the namespace, the resources not listed have none.
+	// +optional
+	Min v1.ResourceList `json:"min,omitempty"`
+	// Max is the capacity the namespace may use by borrowing the unused Min of the other namespaces,
+	// the resources not listed are not limited.
+	// +optional
+	Max v1.ResourceList `json:"max,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticQuotaList is a list of ElasticQuota.
+type ElasticQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticQuota `json:"items"`
+}
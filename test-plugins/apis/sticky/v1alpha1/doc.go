@@ -0,0 +1,6 @@
+// +k8s:deepcopy-gen=package
+// +groupName=scheduling.toys.io
+
+// Package v1alpha1 is the v1alpha1 version of the StickyBinding API, the record of
+// which nodes or topology domains a workload or a pod is pinned to.
+package v1alpha1 // import "test-plugins/apis/sticky/v1alpha1"
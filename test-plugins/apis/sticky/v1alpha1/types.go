@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StickyMode selects how strictly a pod sticks to its sticky nodes.
+type StickyMode string
+
+const (
+	// StickyModeRequired only sticky nodes pass Filter.
+	StickyModeRequired StickyMode = "required"
+	// StickyModePreferred every node passes Filter, sticky nodes get the highest score.
+	StickyModePreferred StickyMode = "preferred"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+
+// StickyBinding pins the pods of a workload, or a single pod, to nodes or to topology domains.
+// It is the source of truth StickyPod reads in PreFilter and writes in PostBind, the
+// sticky-nodes annotation on the owner is only read when there is no binding.
+type StickyBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   StickyBindingSpec   `json:"spec"`
+	Status StickyBindingStatus `json:"status,omitempty"`
+}
+
+// StickyBindingSpec is where the target is pinned to.
+type StickyBindingSpec struct {
+	// Target is the workload or the pod the binding applies to.
+	Target StickyTarget `json:"target"`
+	// NodeNames the target sticks to, earlier nodes are preferred when Ordered.
+	// +optional
+	NodeNames []string `json:"nodeNames,omitempty"`
+	// TopologyKey is the node label key the target sticks to instead of node names.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
+	// Domains are the TopologyKey label values the target sticks to.
+	// +optional
+	Domains []string `json:"domains,omitempty"`
+	// Mode is required or preferred, defaults to the plugin defaultMode.
+	// +optional
+	Mode StickyMode `json:"mode,omitempty"`
+	// Ordered prefers the nodes or domains listed earlier.
+	// +optional
+	Ordered bool `json:"ordered,omitempty"`
+}
+
+// StickyTarget identifies the pods of a binding, in the namespace of the binding.
+type StickyTarget struct {
+	// Kind of the pod owner, e.g. StatefulSet or ReplicaSet.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Name of the pod owner.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// PodName pins a single pod with a stable name, e.g. a StatefulSet replica.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+}
+
+// StickyBindingStatus records where the target was placed.
+type StickyBindingStatus struct {
+	// History of the placements, newest last, capped by the plugin.
+	// +optional
+	History []StickyBindingRecord `json:"history,omitempty"`
+}
+
+// StickyBindingRecord is one placement of a pod of the target.
+type StickyBindingRecord struct {
+	// PodName of the placed pod.
+	PodName string `json:"podName"`
+	// NodeName the pod was bound to.
+	NodeName string `json:"nodeName"`
+	// Domain is the TopologyKey label value of the node, if any.
+	// +optional
+	Domain string `json:"domain,omitempty"`
+	// Time the pod was bound.
+	Time metav1.Time `json:"time"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StickyBindingList is a list of StickyBinding.
+type StickyBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []StickyBinding `json:"items"`
+}
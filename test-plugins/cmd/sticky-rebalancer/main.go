@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/klog/v2"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	localCommon "test-plugins/common"
+	"test-plugins/generated/clientset/versioned"
+	stickyinformers "test-plugins/generated/informers/externalversions"
+	"test-plugins/rebalancer"
+)
+
+func main() {
+	annotationKey := flag.String("annotation-key", "sticky-nodes", "owner annotation listing the sticky nodes, same as the StickyPod annotationKey arg")
+	topologyKey := flag.String("topology-key", "", "node label the sticky-domains of an owner refer to by default, same as the StickyPod topologyKey arg")
+	maxReplicas := flag.Int("max-replicas-per-node", 0, "pods of an owner a sticky node may hold, same as the StickyPod maxReplicasPerNode arg, 0 for no limit")
+	defaultMode := flag.String("default-mode", "required", "sticky mode of the owners without sticky-mode, same as the StickyPod defaultMode arg; pods only preferring their sticky nodes are not evicted")
+	useBindings := flag.Bool("use-sticky-bindings", false, "read the StickyBindings before the owner annotations, same as the StickyPod useStickyBindings arg")
+	interval := flag.Duration("interval", time.Minute, "interval between two scans of the pods")
+	dryRun := flag.Bool("dry-run", false, "send the evictions as server side dry-run, no pod is evicted")
+	qps := flag.Float64("eviction-qps", 0.1, "evictions per second across all owners")
+	burst := flag.Int("eviction-burst", 1, "evictions allowed at once")
+	ownerBackoff := flag.Duration("owner-backoff", 5*time.Minute, "wait after evicting a pod of an owner before evicting another one, doubled while its pods keep running away")
+	ownerMaxBackoff := flag.Duration("owner-max-backoff", time.Hour, "maximum wait between two evictions of the pods of an owner")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	localCommon.NewClientSet()
+	factory := informers.NewSharedInformerFactory(localCommon.K8sClientSet, 0)
+	opts := rebalancer.Options{
+		AnnotationKey:      *annotationKey,
+		TopologyKey:        *topologyKey,
+		MaxReplicasPerNode: int32(*maxReplicas),
+		DefaultMode:        configv1beta2.StickyMode(*defaultMode),
+		Interval:           *interval,
+		DryRun:             *dryRun,
+		EvictionQPS:        float32(*qps),
+		EvictionBurst:      *burst,
+		OwnerBackoff:       *ownerBackoff,
+		OwnerMaxBackoff:    *ownerMaxBackoff,
+	}
+	var bindingFactory stickyinformers.SharedInformerFactory
+	if *useBindings {
+		client, err := versioned.NewForConfig(localCommon.K8sRestConfig)
+		if err != nil {
+			klog.Fatalf("create StickyBinding client: %v", err)
+		}
+		bindingFactory = stickyinformers.NewSharedInformerFactory(client, 0)
+		opts.Bindings = bindingFactory.Scheduling().V1alpha1().StickyBindings()
+	}
+	r := rebalancer.New(localCommon.K8sClientSet, factory, opts)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	factory.Start(ctx.Done())
+	if bindingFactory != nil {
+		bindingFactory.Start(ctx.Done())
+	}
+	if err := r.Run(ctx); err != nil {
+		klog.Fatalf("sticky rebalancer stopped: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/klog/v2"
+	configv1 "k8s.io/kube-scheduler/config/v1"
+	"k8s.io/kubernetes/cmd/kube-scheduler/app/options"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config/scheme"
+
+	"test-plugins/simulator"
+)
+
+// sticky-simulator places pods into a dumped cluster, e.g.
+//
+//	kubectl get nodes,pods,statefulsets,replicasets -A -o json > cluster.json
+//	sticky-simulator -snapshot cluster.json -pods pods.yaml -config scheduler-config.yaml
+func main() {
+	snapshotFiles := flag.String("snapshot", "", "comma separated files with the nodes, pods and pod owners of the cluster, as dumped by kubectl get -o json|yaml")
+	podFiles := flag.String("pods", "", "comma separated files with the pods to place, in order")
+	configFile := flag.String("config", "", "KubeSchedulerConfiguration to simulate, the default profile if empty")
+	schedulerName := flag.String("scheduler-name", "", "profile of the config to use, the first one if empty")
+	extender := flag.String("extender", simulator.ExtenderFilter, "extender logic to run: filter (filter and prioritize), allinone or none")
+	extenderWeight := flag.Int64("extender-weight", 1, "weight of the extender scores")
+	output := flag.String("o", "text", "output format: text or json")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *snapshotFiles == "" || *podFiles == "" {
+		klog.Exit("-snapshot and -pods are required")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	profile, err := loadProfile(klog.FromContext(ctx), *configFile, *schedulerName)
+	if err != nil {
+		klog.Exitf("load scheduler config: %v", err)
+	}
+	snapshot, err := simulator.LoadSnapshot(strings.Split(*snapshotFiles, ",")...)
+	if err != nil {
+		klog.Exitf("load snapshot: %v", err)
+	}
+	pods, err := simulator.LoadPods(strings.Split(*podFiles, ",")...)
+	if err != nil {
+		klog.Exitf("load pods: %v", err)
+	}
+
+	sim, err := simulator.New(ctx, snapshot, simulator.Options{
+		Profile:        profile,
+		Extender:       *extender,
+		ExtenderWeight: *extenderWeight,
+	})
+	if err != nil {
+		klog.Exitf("create simulator: %v", err)
+	}
+	report, err := sim.Run(ctx, pods)
+	if err != nil {
+		klog.Exitf("simulate: %v", err)
+	}
+
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	default:
+		err = report.Print(os.Stdout)
+	}
+	if err != nil {
+		klog.Exit(err)
+	}
+}
+
+// loadProfile returns the profile of the config file, or the default profile.
+func loadProfile(logger klog.Logger, file, schedulerName string) (*config.KubeSchedulerProfile, error) {
+	cfg := &config.KubeSchedulerConfiguration{}
+	if file == "" {
+		// 和 kube-scheduler 没有 --config 时一样用默认配置
+		versioned := configv1.KubeSchedulerConfiguration{}
+		scheme.Scheme.Default(&versioned)
+		if err := scheme.Scheme.Convert(&versioned, cfg, nil); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if cfg, err = options.LoadConfigFromFile(logger, file); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range cfg.Profiles {
+		if schedulerName == "" || cfg.Profiles[i].SchedulerName == schedulerName {
+			return &cfg.Profiles[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no profile %q in the config", schedulerName)
+}
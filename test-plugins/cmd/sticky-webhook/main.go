@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/klog/v2"
+
+	localCommon "test-plugins/common"
+	"test-plugins/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":8443", "address the webhook listens on")
+	certFile := flag.String("tls-cert-file", "/etc/webhook/certs/tls.crt", "TLS certificate served to the apiserver")
+	keyFile := flag.String("tls-private-key-file", "/etc/webhook/certs/tls.key", "TLS private key of the certificate")
+	annotationKey := flag.String("annotation-key", "sticky-nodes", "owner annotation listing the sticky nodes, same as the StickyPod annotationKey arg")
+	maxNodes := flag.Int("max-nodes", 16, "longest sticky node list allowed, 0 means no limit")
+	schedulerName := flag.String("scheduler-name", "", "scheduler profile with StickyPod enabled, pods of sticky owners are mutated to use it; empty disables pod mutation")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	localCommon.NewClientSet()
+	factory := informers.NewSharedInformerFactory(localCommon.K8sClientSet, 0)
+	server := &webhook.Server{
+		Client:        localCommon.K8sClientSet,
+		Nodes:         factory.Core().V1().Nodes().Lister(),
+		AnnotationKey: *annotationKey,
+		MaxNodes:      *maxNodes,
+		SchedulerName: *schedulerName,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	klog.Infof("sticky webhook listening on %s", *addr)
+	if err := http.ListenAndServeTLS(*addr, *certFile, *keyFile, server.Handler()); err != nil {
+		klog.Fatalf("webhook server stopped: %v", err)
+	}
+}
@@ -11,6 +11,9 @@ import (
 
 var K8sClientSet *kubernetes.Clientset
 
+// K8sRestConfig is the config K8sClientSet is built from, for the clients of the CRDs.
+var K8sRestConfig *rest.Config
+
 func NewClientSet() {
 
 	kubeConfig := os.Getenv("KUBECONFIG")
@@ -29,5 +32,6 @@ func NewClientSet() {
 		klog.Fatalf("init client set error %v\n", err)
 	}
 	K8sClientSet = client
+	K8sRestConfig = config
 	klog.Infof("Initializing k8s client successful")
 }
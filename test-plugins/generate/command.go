@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand returns the generate-config subcommand, it writes the scheduler config and the
+// manifests deploying the scheduler and the extender to stdout.
+func NewCommand() *cobra.Command {
+	o := &Options{}
+	var stickyArgsFile string
+	cmd := &cobra.Command{
+		Use:   "generate-config",
+		Short: "Print a validated scheduler config enabling StickyPod and the extender, with the manifests to deploy them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if stickyArgsFile != "" {
+				data, err := os.ReadFile(stickyArgsFile)
+				if err != nil {
+					return err
+				}
+				o.StickyArgs = data
+			}
+			cfg, err := Config(o)
+			if err != nil {
+				return err
+			}
+			objs, err := Manifests(o, cfg)
+			if err != nil {
+				return err
+			}
+			data, err := Encode(objs)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&o.SchedulerName, "scheduler-name", "sticky-scheduler", "schedulerName of the profile, also the name of the generated objects")
+	fs.StringVar(&o.Namespace, "namespace", "kube-system", "namespace of the scheduler and the extender")
+	fs.StringVar(&o.Image, "image", "test-plugins:latest", "image of the scheduler")
+	fs.StringVar(&o.ExtenderURL, "extender-url", "", "urlPrefix of an existing extender, the extender Deployment and Service are generated if empty")
+	fs.StringVar(&o.ExtenderImage, "extender-image", "extender-scheduler:latest", "image of the generated extender")
+	fs.StringVar(&o.ExtenderFilterVerb, "extender-filter-verb", "filter", "filter, or allinone to let the extender choose the node")
+	fs.Int64Var(&o.ExtenderWeight, "extender-weight", 1, "weight of the extender prioritize scores")
+	fs.StringSliceVar(&o.ManagedResources, "extender-managed-resources", nil, "extended resources the extender is called for, all pods if empty")
+	fs.BoolVar(&o.IgnoredByScheduler, "extender-ignored-by-scheduler", false, "leave the fit check of the managed resources to the extender")
+	fs.BoolVar(&o.Ignorable, "extender-ignorable", false, "keep scheduling when the extender is unreachable")
+	fs.StringVar(&o.GPUSliceResource, "gpu-slice-resource", "", "virtual resource of GPU slices the extender allocates per GPU and binds, e.g. toys.io/gpu-mem, off if empty")
+	fs.StringVar(&o.GPUTopologyResource, "gpu-topology-resource", "", "GPU resource, e.g. nvidia.com/gpu, whose multi-GPU pods the extender places within one NVLink group or PCIe switch and binds, off if empty")
+	fs.StringVar(&stickyArgsFile, "sticky-args", "", "YAML file with the StickyPodArgs, the plugin defaults if empty")
+	fs.BoolVar(&o.CapacityQuota, "capacity-quota", false, "enable CapacityQuota in place of DefaultPreemption, it needs the ElasticQuota CRD")
+	return cmd
+}
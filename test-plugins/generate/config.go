@@ -0,0 +1,241 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	configv1 "k8s.io/kube-scheduler/config/v1"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config/scheme"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config/validation"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/names"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"extender-scheduler/routers"
+	"test-plugins/plugins/capacity"
+	"test-plugins/plugins/sticky"
+)
+
+// extenderPort is the port extender-scheduler listens on.
+const extenderPort = 32080
+
+// Options are what the generated config and manifests are made of.
+type Options struct {
+	SchedulerName string
+	Namespace     string
+	// Image is the image of the scheduler with StickyPod built in.
+	Image string
+
+	// ExtenderURL is the urlPrefix of the extender, the Service of the generated extender Deployment if empty.
+	ExtenderURL   string
+	ExtenderImage string
+	// ExtenderFilterVerb is filter, or allinone to let the extender pick the node.
+	ExtenderFilterVerb string
+	ExtenderWeight     int64
+	// ManagedResources are the extended resources the extender is called for, all pods if empty.
+	ManagedResources []string
+	// IgnoredByScheduler makes the scheduler leave the managed resources to the extender.
+	IgnoredByScheduler bool
+	// Ignorable keeps scheduling when the extender is unreachable.
+	Ignorable bool
+
+	// GPUSliceResource is the virtual resource of GPU slices the extender allocates and binds,
+	// off if empty.
+	GPUSliceResource string
+	// GPUTopologyResource is the GPU resource whose multi-GPU pods the extender places by the GPU
+	// topology and binds, off if empty.
+	GPUTopologyResource string
+
+	// StickyArgs are the StickyPodArgs as YAML, the plugin defaults if empty.
+	StickyArgs []byte
+
+	// CapacityQuota enables CapacityQuota in the profile in place of DefaultPreemption.
+	CapacityQuota bool
+}
+
+// extenderServiceName is the name of the Service of the generated extender.
+func (o *Options) extenderServiceName() string {
+	return o.SchedulerName + "-extender"
+}
+
+// extenderURL returns the urlPrefix of the extender.
+func (o *Options) extenderURL() string {
+	if o.ExtenderURL != "" {
+		return o.ExtenderURL
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", o.extenderServiceName(), o.Namespace, extenderPort)
+}
+
+// extenderBinds returns true when the extender chooses the GPUs of the pods, it does so on bind.
+func (o *Options) extenderBinds() bool {
+	return o.GPUSliceResource != "" || o.GPUTopologyResource != ""
+}
+
+// extenderVerbs returns the verbs routers.MyCustomScheduler registers, so the config only
+// names routes the extender serves.
+func extenderVerbs() map[string]bool {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	routers.MyCustomScheduler(r)
+	verbs := make(map[string]bool)
+	for _, route := range r.Routes() {
+		if route.Method == "POST" {
+			verbs[strings.TrimPrefix(route.Path, "/")] = true
+		}
+	}
+	return verbs
+}
+
+// Config returns the KubeSchedulerConfiguration with a profile enabling StickyPod and the extender,
+// and CapacityQuota when asked.
+func Config(o *Options) (*configv1.KubeSchedulerConfiguration, error) {
+	verbs := extenderVerbs()
+	// explain、bind 也是 POST 路由，但不返回 ExtenderFilterResult
+	if o.ExtenderFilterVerb != "filter" && o.ExtenderFilterVerb != "allinone" {
+		return nil, fmt.Errorf("filter verb %q is neither filter nor allinone", o.ExtenderFilterVerb)
+	}
+	if !verbs[o.ExtenderFilterVerb] {
+		return nil, fmt.Errorf("the extender doesn't serve filter verb %q", o.ExtenderFilterVerb)
+	}
+	extender := configv1.Extender{
+		URLPrefix:   o.extenderURL(),
+		FilterVerb:  o.ExtenderFilterVerb,
+		Weight:      o.ExtenderWeight,
+		HTTPTimeout: metav1.Duration{Duration: 30 * time.Second},
+		// extender 的 handler 都读 ExtenderArgs.Nodes，不支持只传节点名
+		NodeCacheCapable: false,
+		Ignorable:        o.Ignorable,
+	}
+	// allinone 已经选好了节点，不需要再打分
+	if o.ExtenderFilterVerb != "allinone" && verbs["prioritize"] {
+		extender.PrioritizeVerb = "prioritize"
+	}
+	for _, name := range o.ManagedResources {
+		extender.ManagedResources = append(extender.ManagedResources, configv1.ExtenderManagedResource{
+			Name:               name,
+			IgnoredByScheduler: o.IgnoredByScheduler,
+		})
+	}
+	if o.GPUSliceResource != "" {
+		// 节点上没有这个资源，scheduler 不能检查
+		extender.ManagedResources = append(extender.ManagedResources, configv1.ExtenderManagedResource{
+			Name:               o.GPUSliceResource,
+			IgnoredByScheduler: true,
+		})
+	}
+	if o.GPUTopologyResource != "" {
+		// GPU 总数还是由 scheduler 检查，extender 只管选哪几块
+		extender.ManagedResources = append(extender.ManagedResources, configv1.ExtenderManagedResource{
+			Name: o.GPUTopologyResource,
+		})
+	}
+	if o.extenderBinds() {
+		// extender 绑定时才选 GPU
+		if !verbs["bind"] {
+			return nil, fmt.Errorf("the extender doesn't serve bind, GPU slices and topology need it")
+		}
+		extender.BindVerb = "bind"
+	}
+
+	profile := configv1.KubeSchedulerProfile{
+		SchedulerName: ptr.To(o.SchedulerName),
+		Plugins: &configv1.Plugins{
+			MultiPoint: configv1.PluginSet{Enabled: []configv1.Plugin{{Name: sticky.Name}}},
+		},
+	}
+	if len(o.StickyArgs) != 0 {
+		args, err := yaml.YAMLToJSON(o.StickyArgs)
+		if err != nil {
+			return nil, fmt.Errorf("StickyPod args: %w", err)
+		}
+		profile.PluginConfig = []configv1.PluginConfig{{Name: sticky.Name, Args: runtime.RawExtension{Raw: args}}}
+	}
+	if o.CapacityQuota {
+		// DefaultPreemption 只看优先级，会跨 quota 抢占，两个都在的话哪个先跑说不准
+		profile.Plugins.MultiPoint.Enabled = append(profile.Plugins.MultiPoint.Enabled, configv1.Plugin{Name: capacity.Name})
+		profile.Plugins.MultiPoint.Disabled = []configv1.Plugin{{Name: names.DefaultPreemption}}
+	}
+
+	// 零值会被写成 burst: 0 之类，拿默认值写出来更清楚
+	defaulted := configv1.KubeSchedulerConfiguration{}
+	scheme.Scheme.Default(&defaulted)
+	cfg := &configv1.KubeSchedulerConfiguration{
+		TypeMeta:         metav1.TypeMeta{APIVersion: configv1.SchemeGroupVersion.String(), Kind: "KubeSchedulerConfiguration"},
+		ClientConnection: defaulted.ClientConnection,
+		LeaderElection:   defaulted.LeaderElection,
+		Profiles:         []configv1.KubeSchedulerProfile{profile},
+		Extenders:        []configv1.Extender{extender},
+	}
+	// 和默认调度器抢同一个 lease 的话只有一个能跑
+	cfg.LeaderElection.ResourceName = o.SchedulerName
+	cfg.LeaderElection.ResourceNamespace = o.Namespace
+
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the config the way kube-scheduler loads it, the StickyPod args the way
+// the plugin decodes them, and that no profile runs CapacityQuota along with DefaultPreemption.
+func Validate(cfg *configv1.KubeSchedulerConfiguration) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	obj, _, err := scheme.Codecs.UniversalDecoder().Decode(data, nil, nil)
+	if err != nil {
+		return fmt.Errorf("decode config: %w", err)
+	}
+	internal, ok := obj.(*config.KubeSchedulerConfiguration)
+	if !ok {
+		return fmt.Errorf("decoded config is a %T", obj)
+	}
+	if err := validation.ValidateKubeSchedulerConfiguration(internal); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	for _, profile := range internal.Profiles {
+		if runsPostFilter(profile.Plugins, capacity.Name) && runsPostFilter(profile.Plugins, names.DefaultPreemption) {
+			return fmt.Errorf("profile %s: %s replaces %s, disable %s", profile.SchedulerName, capacity.Name, names.DefaultPreemption, names.DefaultPreemption)
+		}
+		for _, pc := range profile.PluginConfig {
+			if pc.Name != sticky.Name {
+				continue
+			}
+			if _, err := sticky.DecodeArgs(pc.Args); err != nil {
+				return fmt.Errorf("profile %s: %w", profile.SchedulerName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runsPostFilter returns true when the plugin is enabled at PostFilter in the defaulted plugins
+// of a profile, directly or by MultiPoint.
+func runsPostFilter(plugins *config.Plugins, name string) bool {
+	if plugins == nil {
+		return false
+	}
+	for _, p := range plugins.PostFilter.Enabled {
+		if p.Name == name {
+			return true
+		}
+	}
+	for _, p := range plugins.PostFilter.Disabled {
+		if p.Name == name || p.Name == "*" {
+			return false
+		}
+	}
+	for _, p := range plugins.MultiPoint.Enabled {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
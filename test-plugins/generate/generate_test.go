@@ -0,0 +1,190 @@
+package generate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func defaultOptions() *Options {
+	return &Options{
+		SchedulerName:      "sticky-scheduler",
+		Namespace:          "kube-system",
+		Image:              "test-plugins:latest",
+		ExtenderImage:      "extender-scheduler:latest",
+		ExtenderFilterVerb: "filter",
+		ExtenderWeight:     1,
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(o *Options)
+		wantPrioritize string
+		wantBind       string
+		wantErr        string
+	}{
+		{
+			name:           "filter",
+			modify:         func(o *Options) {},
+			wantPrioritize: "prioritize",
+		},
+		{
+			// allinone 只返回一个节点，打分没有意义
+			name:   "allinone",
+			modify: func(o *Options) { o.ExtenderFilterVerb = "allinone" },
+		},
+		{
+			name: "sticky args",
+			modify: func(o *Options) {
+				o.StickyArgs = []byte("defaultMode: required\nsupportedKinds: [StatefulSet]\n")
+			},
+			wantPrioritize: "prioritize",
+		},
+		{
+			// GPU 切片由 extender 绑定
+			name:           "gpu slices",
+			modify:         func(o *Options) { o.GPUSliceResource = "toys.io/gpu-mem" },
+			wantPrioritize: "prioritize",
+			wantBind:       "bind",
+		},
+		{
+			name:           "gpu topology",
+			modify:         func(o *Options) { o.GPUTopologyResource = "nvidia.com/gpu" },
+			wantPrioritize: "prioritize",
+			wantBind:       "bind",
+		},
+		{
+			name:    "not a filter verb",
+			modify:  func(o *Options) { o.ExtenderFilterVerb = "bind" },
+			wantErr: `filter verb "bind" is neither filter nor allinone`,
+		},
+		{
+			name:    "invalid sticky args",
+			modify:  func(o *Options) { o.StickyArgs = []byte("defaultMode: sometimes\n") },
+			wantErr: "invalid StickyPod args",
+		},
+		{
+			name:    "invalid extender",
+			modify:  func(o *Options) { o.ExtenderWeight = 0 },
+			wantErr: "invalid config",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			tt.modify(o)
+			cfg, err := Config(o)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verbs := extenderVerbs()
+			extender := cfg.Extenders[0]
+			for _, verb := range []string{extender.FilterVerb, extender.PrioritizeVerb, extender.BindVerb, extender.PreemptVerb} {
+				if verb != "" && !verbs[verb] {
+					t.Errorf("verb %q is not a route of the extender", verb)
+				}
+			}
+			if extender.PrioritizeVerb != tt.wantPrioritize {
+				t.Errorf("prioritizeVerb %q, want %q", extender.PrioritizeVerb, tt.wantPrioritize)
+			}
+			if extender.BindVerb != tt.wantBind {
+				t.Errorf("bindVerb %q, want %q", extender.BindVerb, tt.wantBind)
+			}
+			if extender.URLPrefix != "http://sticky-scheduler-extender.kube-system.svc:32080" {
+				t.Errorf("urlPrefix %s", extender.URLPrefix)
+			}
+		})
+	}
+}
+
+func TestConfigCapacityQuota(t *testing.T) {
+	o := defaultOptions()
+	o.CapacityQuota = true
+	cfg, err := Config(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multiPoint := cfg.Profiles[0].Plugins.MultiPoint
+	if got := multiPoint.Enabled[len(multiPoint.Enabled)-1].Name; got != "CapacityQuota" {
+		t.Errorf("last enabled plugin %s, want CapacityQuota", got)
+	}
+	if len(multiPoint.Disabled) != 1 || multiPoint.Disabled[0].Name != "DefaultPreemption" {
+		t.Errorf("disabled plugins %v, want DefaultPreemption", multiPoint.Disabled)
+	}
+
+	// 用户自己写的 profile 把 DefaultPreemption 又打开了
+	multiPoint.Disabled = nil
+	cfg.Profiles[0].Plugins.MultiPoint = multiPoint
+	err = Validate(cfg)
+	if want := "CapacityQuota replaces DefaultPreemption"; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestManifests(t *testing.T) {
+	o := defaultOptions()
+	cfg, err := Config(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := Manifests(o, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	want := "ServiceAccount ClusterRoleBinding ClusterRoleBinding ClusterRole ClusterRoleBinding RoleBinding ConfigMap Deployment Deployment Service"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("kinds %s, want %s", got, want)
+	}
+
+	// 用现成的 extender 时不生成 extender 的 Deployment 和 Service
+	o.ExtenderURL = "http://extender.example:32080"
+	objs, err = Manifests(o, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != len(kinds)-2 {
+		t.Errorf("got %d objects with an existing extender", len(objs))
+	}
+
+	// ConfigMap 里是启用了 StickyPod 的配置
+	cm := objs[6].(*v1.ConfigMap)
+	if !strings.Contains(cm.Data[configFile], "name: StickyPod") {
+		t.Errorf("config doesn't enable StickyPod:\n%s", cm.Data[configFile])
+	}
+	if _, err := Encode(objs); err != nil {
+		t.Fatal(err)
+	}
+
+	// ignoredByScheduler 的资源由 extender 统计，要带上同样的参数和 list Pod 的权限
+	o.ExtenderURL = ""
+	o.ManagedResources = []string{"nvidia.com/gpu"}
+	o.IgnoredByScheduler = true
+	objs, err = Manifests(o, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != len(kinds)+3 {
+		t.Fatalf("got %d objects with ignoredByScheduler resources", len(objs))
+	}
+	extender := objs[len(objs)-2].(*appsv1.Deployment).Spec.Template.Spec
+	if extender.ServiceAccountName != "sticky-scheduler-extender" ||
+		!reflect.DeepEqual(extender.Containers[0].Args, []string{"-ignored-by-scheduler-resources=nvidia.com/gpu"}) {
+		t.Errorf("extender runs as %q with args %v", extender.ServiceAccountName, extender.Containers[0].Args)
+	}
+}
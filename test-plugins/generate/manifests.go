@@ -0,0 +1,278 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	configv1 "k8s.io/kube-scheduler/config/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// configDir is where the ConfigMap with the scheduler config is mounted.
+	configDir  = "/etc/kubernetes/sticky-scheduler"
+	configFile = "config.yaml"
+)
+
+// Manifests returns the objects deploying the scheduler with the config, and the extender
+// when Options.ExtenderURL is empty.
+func Manifests(o *Options, cfg *configv1.KubeSchedulerConfiguration) ([]runtime.Object, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	configMeta := o.meta(o.SchedulerName)
+	configMeta.Name = o.SchedulerName + "-config"
+	objs := []runtime.Object{
+		&v1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: o.meta(o.SchedulerName),
+		},
+		// kube-scheduler 自带的权限，lease 只能用 kube-scheduler 这个名字，下面的 ClusterRole 补上
+		o.clusterRoleBinding(o.SchedulerName+"-as-kube-scheduler", "system:kube-scheduler"),
+		o.clusterRoleBinding(o.SchedulerName+"-as-volume-scheduler", "system:volume-scheduler"),
+		o.clusterRole(),
+		o.clusterRoleBinding(o.SchedulerName, o.SchedulerName),
+		&rbacv1.RoleBinding{
+			TypeMeta: metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      o.SchedulerName + "-extension-apiserver-authentication-reader",
+				Namespace: metav1.NamespaceSystem,
+			},
+			RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "extension-apiserver-authentication-reader"},
+			Subjects: o.subjects(),
+		},
+		&v1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: configMeta,
+			Data:       map[string]string{configFile: string(data)},
+		},
+		o.schedulerDeployment(),
+	}
+	if o.ExtenderURL == "" {
+		if o.extenderNeedsClient() {
+			objs = append(objs, o.extenderRBAC()...)
+		}
+		objs = append(objs, o.extenderDeployment(), o.extenderService())
+	}
+	return objs, nil
+}
+
+// Encode writes the objects as a multi document YAML.
+func Encode(objs []runtime.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, obj := range objs {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("encode %T: %w", obj, err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *Options) meta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: o.Namespace, Labels: map[string]string{"app": name}}
+}
+
+func (o *Options) subjects() []rbacv1.Subject {
+	return []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: o.SchedulerName, Namespace: o.Namespace}}
+}
+
+func (o *Options) clusterRoleBinding(name, role string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role},
+		Subjects:   o.subjects(),
+	}
+}
+
+// clusterRole grants what system:kube-scheduler lacks: the lease of the scheduler name,
+// the owners StickyPod reads and annotates, the StickyBindings, and the ElasticQuotas CapacityQuota reads.
+func (o *Options) clusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: o.SchedulerName},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups:     []string{"coordination.k8s.io"},
+				Resources:     []string{"leases"},
+				ResourceNames: []string{o.SchedulerName},
+				Verbs:         []string{"get", "update"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"replicationcontrollers"},
+				Verbs:     []string{"get", "list", "watch", "patch"},
+			},
+			{
+				APIGroups: []string{"apps"},
+				Resources: []string{"statefulsets", "replicasets", "daemonsets"},
+				Verbs:     []string{"get", "list", "watch", "patch"},
+			},
+			{
+				APIGroups: []string{"batch"},
+				Resources: []string{"jobs"},
+				Verbs:     []string{"get", "list", "watch", "patch"},
+			},
+			{
+				APIGroups: []string{"scheduling.toys.io"},
+				Resources: []string{"stickybindings"},
+				Verbs:     []string{"get", "list", "watch", "create", "update"},
+			},
+			{
+				APIGroups: []string{"quota.scheduling.toys.io"},
+				Resources: []string{"elasticquotas"},
+				Verbs:     []string{"get", "list", "watch"},
+			},
+		},
+	}
+}
+
+func (o *Options) schedulerDeployment() *appsv1.Deployment {
+	meta := o.meta(o.SchedulerName)
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: meta.Labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: meta.Labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: o.SchedulerName,
+					Containers: []v1.Container{{
+						Name:  "scheduler",
+						Image: o.Image,
+						Args:  []string{"--config=" + configDir + "/" + configFile, "--v=3"},
+						LivenessProbe: &v1.Probe{
+							ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{
+								Path:   "/healthz",
+								Port:   intstr.FromInt32(10259),
+								Scheme: v1.URISchemeHTTPS,
+							}},
+							InitialDelaySeconds: 15,
+						},
+						VolumeMounts: []v1.VolumeMount{{Name: "config", MountPath: configDir, ReadOnly: true}},
+					}},
+					Volumes: []v1.Volume{{
+						Name: "config",
+						VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+							LocalObjectReference: v1.LocalObjectReference{Name: o.SchedulerName + "-config"},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// extenderNeedsClient returns true when the extender talks to the API server: to count the
+// resources ignored by the scheduler, and to bind the pods requesting GPU slices.
+func (o *Options) extenderNeedsClient() bool {
+	return (o.IgnoredByScheduler && len(o.ManagedResources) != 0) || o.extenderBinds()
+}
+
+// extenderRBAC returns the ServiceAccount of the extender with the permissions it needs.
+func (o *Options) extenderRBAC() []runtime.Object {
+	name := o.extenderServiceName()
+	// pod informer 统计 scheduler 不检查的资源
+	rules := []rbacv1.PolicyRule{{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"list", "watch"},
+	}}
+	if o.extenderBinds() {
+		// Bind 读节点上的 GPU，给 Pod 写上 GPU 再绑定
+		rules = append(rules,
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "patch"}},
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/binding"}, Verbs: []string{"create"}},
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}},
+		)
+	}
+	return []runtime.Object{
+		&v1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+			ObjectMeta: o.meta(name),
+		},
+		&rbacv1.ClusterRole{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules:      rules,
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: o.Namespace}},
+		},
+	}
+}
+
+func (o *Options) extenderDeployment() *appsv1.Deployment {
+	meta := o.meta(o.extenderServiceName())
+	// extender 和 scheduler 的 managedResources 保持一致
+	var args []string
+	if len(o.ManagedResources) != 0 {
+		if o.IgnoredByScheduler {
+			args = append(args, "-ignored-by-scheduler-resources="+strings.Join(o.ManagedResources, ","))
+		} else {
+			args = append(args, "-managed-resources="+strings.Join(o.ManagedResources, ","))
+		}
+	}
+	if o.GPUSliceResource != "" {
+		args = append(args, "-gpu-slice-resource="+o.GPUSliceResource)
+	}
+	if o.GPUTopologyResource != "" {
+		args = append(args, "-gpu-topology-resource="+o.GPUTopologyResource)
+	}
+	var serviceAccount string
+	if o.extenderNeedsClient() {
+		serviceAccount = meta.Name
+	}
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{MatchLabels: meta.Labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: meta.Labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: serviceAccount,
+					Containers: []v1.Container{{
+						Name:  "extender",
+						Image: o.ExtenderImage,
+						Args:  args,
+						Ports: []v1.ContainerPort{{Name: "http", ContainerPort: extenderPort}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func (o *Options) extenderService() *v1.Service {
+	meta := o.meta(o.extenderServiceName())
+	return &v1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: meta,
+		Spec: v1.ServiceSpec{
+			Selector: meta.Labels,
+			Ports:    []v1.ServicePort{{Name: "http", Port: extenderPort, TargetPort: intstr.FromString("http")}},
+		},
+	}
+}
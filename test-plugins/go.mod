@@ -2,163 +2,194 @@ module test-plugins
 
 go 1.24.1
 
-require github.com/prometheus/common v0.26.0 // indirect; indirecttet
-
 require (
-	k8s.io/api v0.22.12
-	k8s.io/apimachinery v0.22.14-rc.0
-	k8s.io/client-go v0.22.12
-	k8s.io/component-base v0.22.12
-	k8s.io/klog/v2 v2.9.0
-	k8s.io/kubernetes v1.22.12
+	extender-scheduler v0.0.0
+	github.com/gin-gonic/gin v1.10.0
+	github.com/google/go-cmp v0.6.0
+	github.com/spf13/cobra v1.8.1
+	k8s.io/api v0.32.3
+	k8s.io/apimachinery v0.32.3
+	k8s.io/client-go v0.32.3
+	k8s.io/component-base v0.32.3
+	k8s.io/component-helpers v0.32.3
+	k8s.io/klog/v2 v2.130.1
+	k8s.io/kube-scheduler v0.32.3
+	k8s.io/kubernetes v1.32.3
+	k8s.io/utils v0.0.0-20241104100929-3ea5e8cea738
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
-	github.com/Azure/go-ansiterm v0.0.0-20210617225240-d185dfc1b5a1 // indirect
+	cel.dev/expr v0.18.0 // indirect
+	github.com/Azure/go-ansiterm v0.0.0-20230124172434-306776ec8161 // indirect
 	github.com/NYTimes/gziphandler v1.1.1 // indirect
-	github.com/PuerkitoBio/purell v1.1.1 // indirect
-	github.com/PuerkitoBio/urlesc v0.0.0-20170810143723-de5bf2ad4578 // indirect
+	github.com/antlr4-go/antlr/v4 v4.13.0 // indirect
+	github.com/asaskevich/govalidator v0.0.0-20190424111038-f61b66f89f4a // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
-	github.com/bits-and-blooms/bitset v1.2.0 // indirect
-	github.com/blang/semver v3.5.1+incompatible // indirect
-	github.com/cespare/xxhash/v2 v2.1.1 // indirect
-	github.com/coreos/go-semver v0.3.0 // indirect
-	github.com/coreos/go-systemd/v22 v22.3.2 // indirect
-	github.com/cyphar/filepath-securejoin v0.2.2 // indirect
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/docker/distribution v2.7.1+incompatible // indirect
-	github.com/emicklei/go-restful v2.9.5+incompatible // indirect
-	github.com/evanphx/json-patch v4.11.0+incompatible // indirect
-	github.com/felixge/httpsnoop v1.0.1 // indirect
-	github.com/go-logr/logr v0.4.0 // indirect
-	github.com/go-openapi/jsonpointer v0.19.5 // indirect
-	github.com/go-openapi/jsonreference v0.19.5 // indirect
-	github.com/go-openapi/swag v0.19.14 // indirect
+	github.com/blang/semver/v4 v4.0.0 // indirect
+	github.com/bytedance/sonic v1.11.6 // indirect
+	github.com/bytedance/sonic/loader v0.1.1 // indirect
+	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/cloudwego/base64x v0.1.4 // indirect
+	github.com/cloudwego/iasm v0.2.0 // indirect
+	github.com/coreos/go-semver v0.3.1 // indirect
+	github.com/coreos/go-systemd/v22 v22.5.0 // indirect
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/distribution/reference v0.6.0 // indirect
+	github.com/emicklei/go-restful/v3 v3.11.0 // indirect
+	github.com/felixge/httpsnoop v1.0.4 // indirect
+	github.com/fsnotify/fsnotify v1.7.0 // indirect
+	github.com/fxamacker/cbor/v2 v2.7.0 // indirect
+	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
+	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-openapi/jsonpointer v0.21.0 // indirect
+	github.com/go-openapi/jsonreference v0.20.2 // indirect
+	github.com/go-openapi/swag v0.23.0 // indirect
+	github.com/go-playground/locales v0.14.1 // indirect
+	github.com/go-playground/universal-translator v0.18.1 // indirect
+	github.com/go-playground/validator/v10 v10.20.0 // indirect
+	github.com/goccy/go-json v0.10.2 // indirect
 	github.com/gogo/protobuf v1.3.2 // indirect
-	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/google/go-cmp v0.5.5 // indirect
-	github.com/google/gofuzz v1.1.0 // indirect
-	github.com/google/uuid v1.1.2 // indirect
-	github.com/googleapis/gnostic v0.5.5 // indirect
+	github.com/golang/protobuf v1.5.4 // indirect
+	github.com/google/btree v1.0.1 // indirect
+	github.com/google/cel-go v0.22.0 // indirect
+	github.com/google/gnostic-models v0.6.8 // indirect
+	github.com/google/gofuzz v1.2.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
 	github.com/grpc-ecosystem/go-grpc-prometheus v1.2.0 // indirect
-	github.com/grpc-ecosystem/grpc-gateway v1.16.0 // indirect
-	github.com/imdario/mergo v0.3.5 // indirect
-	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.20.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
-	github.com/json-iterator/go v1.1.11 // indirect
-	github.com/mailru/easyjson v0.7.6 // indirect
-	github.com/matttproud/golang_protobuf_extensions v1.0.2-0.20181231171920-c182affec369 // indirect
-	github.com/moby/term v0.0.0-20210610120745-9d4ed1856297 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/klauspost/cpuid/v2 v2.2.7 // indirect
+	github.com/leodido/go-urn v1.4.0 // indirect
+	github.com/mailru/easyjson v0.7.7 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/moby/term v0.5.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
-	github.com/modern-go/reflect2 v1.0.1 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/opencontainers/go-digest v1.0.0 // indirect
-	github.com/opencontainers/runc v1.0.2 // indirect
-	github.com/opencontainers/selinux v1.8.2 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.2 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/prometheus/client_golang v1.11.0 // indirect
-	github.com/prometheus/client_model v0.2.0 // indirect
-	github.com/prometheus/procfs v0.6.0 // indirect
-	github.com/spf13/cobra v1.1.3 // indirect
+	github.com/prometheus/client_golang v1.19.1 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	go.etcd.io/etcd/api/v3 v3.5.0 // indirect
-	go.etcd.io/etcd/client/pkg/v3 v3.5.0 // indirect
-	go.etcd.io/etcd/client/v3 v3.5.0 // indirect
-	go.opentelemetry.io/contrib v0.20.0 // indirect
-	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.20.0 // indirect
-	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.20.0 // indirect
-	go.opentelemetry.io/otel v0.20.0 // indirect
-	go.opentelemetry.io/otel/exporters/otlp v0.20.0 // indirect
-	go.opentelemetry.io/otel/metric v0.20.0 // indirect
-	go.opentelemetry.io/otel/sdk v0.20.0 // indirect
-	go.opentelemetry.io/otel/sdk/export/metric v0.20.0 // indirect
-	go.opentelemetry.io/otel/sdk/metric v0.20.0 // indirect
-	go.opentelemetry.io/otel/trace v0.20.0 // indirect
-	go.opentelemetry.io/proto/otlp v0.7.0 // indirect
-	go.uber.org/atomic v1.7.0 // indirect
-	go.uber.org/multierr v1.6.0 // indirect
-	go.uber.org/zap v1.17.0 // indirect
-	golang.org/x/crypto v0.0.0-20210220033148-5ea612d1eb83 // indirect
-	golang.org/x/net v0.0.0-20211209124913-491a49abca63 // indirect
-	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d // indirect
-	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c // indirect
-	golang.org/x/sys v0.0.0-20210616094352-59db8d763f22 // indirect
-	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d // indirect
-	golang.org/x/text v0.3.6 // indirect
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
-	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
-	google.golang.org/appengine v1.6.5 // indirect
-	google.golang.org/genproto v0.0.0-20210602131652-f16073e35f0c // indirect
-	google.golang.org/grpc v1.38.0 // indirect
-	google.golang.org/protobuf v1.26.0 // indirect
+	github.com/stoewer/go-strcase v1.3.0 // indirect
+	github.com/twitchyliquid64/golang-asm v0.15.1 // indirect
+	github.com/ugorji/go/codec v1.2.12 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	go.etcd.io/etcd/api/v3 v3.5.16 // indirect
+	go.etcd.io/etcd/client/pkg/v3 v3.5.16 // indirect
+	go.etcd.io/etcd/client/v3 v3.5.16 // indirect
+	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.53.0 // indirect
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.53.0 // indirect
+	go.opentelemetry.io/otel v1.28.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.28.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.27.0 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.28.0 // indirect
+	go.opentelemetry.io/otel/trace v1.28.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.3.1 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	go.uber.org/zap v1.27.0 // indirect
+	golang.org/x/arch v0.8.0 // indirect
+	golang.org/x/crypto v0.28.0 // indirect
+	golang.org/x/exp v0.0.0-20240719175910-8a7402abbf56 // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/oauth2 v0.23.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/sys v0.26.0 // indirect
+	golang.org/x/term v0.25.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+	golang.org/x/time v0.7.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240826202546-f6391c0de4c7 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240826202546-f6391c0de4c7 // indirect
+	google.golang.org/grpc v1.65.0 // indirect
+	google.golang.org/protobuf v1.35.1 // indirect
+	gopkg.in/evanphx/json-patch.v4 v4.12.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
-	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
-	k8s.io/apiserver v0.22.12 // indirect
-	k8s.io/cloud-provider v0.22.12 // indirect
-	k8s.io/component-helpers v0.22.12 // indirect
-	k8s.io/csi-translation-lib v0.22.12 // indirect
-	k8s.io/kube-openapi v0.0.0-20211109043538-20434351676c // indirect
-	k8s.io/kube-scheduler v0.22.12 // indirect
-	k8s.io/mount-utils v0.22.18-rc.0 // indirect
-	k8s.io/utils v0.0.0-20211116205334-6203023598ed // indirect
-	sigs.k8s.io/apiserver-network-proxy/konnectivity-client v0.0.30 // indirect
-	sigs.k8s.io/structured-merge-diff/v4 v4.2.1 // indirect
-	sigs.k8s.io/yaml v1.2.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	k8s.io/apiextensions-apiserver v0.0.0 // indirect
+	k8s.io/apiserver v0.32.3 // indirect
+	k8s.io/cloud-provider v0.0.0 // indirect
+	k8s.io/controller-manager v0.32.3 // indirect
+	k8s.io/csi-translation-lib v0.0.0 // indirect
+	k8s.io/dynamic-resource-allocation v0.0.0 // indirect
+	k8s.io/kms v0.32.3 // indirect
+	k8s.io/kube-openapi v0.0.0-20241105132330-32ad38e42d3f // indirect
+	k8s.io/kubelet v0.32.3 // indirect
+	sigs.k8s.io/apiserver-network-proxy/konnectivity-client v0.31.0 // indirect
+	sigs.k8s.io/json v0.0.0-20241010143419-9aa6b5e7a4b3 // indirect
+	sigs.k8s.io/structured-merge-diff/v4 v4.4.2 // indirect
 )
 
-replace k8s.io/api => k8s.io/api v0.22.12
+replace k8s.io/api => k8s.io/api v0.32.3
+
+replace k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.32.3
+
+replace k8s.io/apimachinery => k8s.io/apimachinery v0.32.3
+
+replace k8s.io/apiserver => k8s.io/apiserver v0.32.3
+
+replace k8s.io/cli-runtime => k8s.io/cli-runtime v0.32.3
+
+replace k8s.io/client-go => k8s.io/client-go v0.32.3
 
-replace k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.22.12
+replace k8s.io/cloud-provider => k8s.io/cloud-provider v0.32.3
 
-replace k8s.io/apimachinery => k8s.io/apimachinery v0.22.14-rc.0
+replace k8s.io/cluster-bootstrap => k8s.io/cluster-bootstrap v0.32.3
 
-replace k8s.io/apiserver => k8s.io/apiserver v0.22.12
+replace k8s.io/code-generator => k8s.io/code-generator v0.32.3
 
-replace k8s.io/cli-runtime => k8s.io/cli-runtime v0.22.12
+replace k8s.io/component-base => k8s.io/component-base v0.32.3
 
-replace k8s.io/client-go => k8s.io/client-go v0.22.12
+replace k8s.io/component-helpers => k8s.io/component-helpers v0.32.3
 
-replace k8s.io/cloud-provider => k8s.io/cloud-provider v0.22.12
+replace k8s.io/controller-manager => k8s.io/controller-manager v0.32.3
 
-replace k8s.io/cluster-bootstrap => k8s.io/cluster-bootstrap v0.22.12
+replace k8s.io/cri-api => k8s.io/cri-api v0.32.3
 
-replace k8s.io/code-generator => k8s.io/code-generator v0.22.18-rc.0
+replace k8s.io/cri-client => k8s.io/cri-client v0.32.3
 
-replace k8s.io/component-base => k8s.io/component-base v0.22.12
+replace k8s.io/csi-translation-lib => k8s.io/csi-translation-lib v0.32.3
 
-replace k8s.io/component-helpers => k8s.io/component-helpers v0.22.12
+replace k8s.io/dynamic-resource-allocation => k8s.io/dynamic-resource-allocation v0.32.3
 
-replace k8s.io/controller-manager => k8s.io/controller-manager v0.22.12
+replace k8s.io/endpointslice => k8s.io/endpointslice v0.32.3
 
-replace k8s.io/cri-api => k8s.io/cri-api v0.22.18-rc.0
+replace k8s.io/externaljwt => k8s.io/externaljwt v0.32.3
 
-replace k8s.io/csi-translation-lib => k8s.io/csi-translation-lib v0.22.12
+replace k8s.io/kms => k8s.io/kms v0.32.3
 
-replace k8s.io/kube-aggregator => k8s.io/kube-aggregator v0.22.12
+replace k8s.io/kube-aggregator => k8s.io/kube-aggregator v0.32.3
 
-replace k8s.io/kube-controller-manager => k8s.io/kube-controller-manager v0.22.12
+replace k8s.io/kube-controller-manager => k8s.io/kube-controller-manager v0.32.3
 
-replace k8s.io/kube-proxy => k8s.io/kube-proxy v0.22.12
+replace k8s.io/kube-proxy => k8s.io/kube-proxy v0.32.3
 
-replace k8s.io/kube-scheduler => k8s.io/kube-scheduler v0.22.12
+replace k8s.io/kube-scheduler => k8s.io/kube-scheduler v0.32.3
 
-replace k8s.io/kubectl => k8s.io/kubectl v0.22.12
+replace k8s.io/kubectl => k8s.io/kubectl v0.32.3
 
-replace k8s.io/kubelet => k8s.io/kubelet v0.22.12
+replace k8s.io/kubelet => k8s.io/kubelet v0.32.3
 
-replace k8s.io/legacy-cloud-providers => k8s.io/legacy-cloud-providers v0.22.12
+replace k8s.io/metrics => k8s.io/metrics v0.32.3
 
-replace k8s.io/metrics => k8s.io/metrics v0.22.12
+replace k8s.io/mount-utils => k8s.io/mount-utils v0.32.3
 
-replace k8s.io/mount-utils => k8s.io/mount-utils v0.22.18-rc.0
+replace k8s.io/pod-security-admission => k8s.io/pod-security-admission v0.32.3
 
-replace k8s.io/pod-security-admission => k8s.io/pod-security-admission v0.22.12
+replace k8s.io/sample-apiserver => k8s.io/sample-apiserver v0.32.3
 
-replace k8s.io/sample-apiserver => k8s.io/sample-apiserver v0.22.12
+replace k8s.io/sample-cli-plugin => k8s.io/sample-cli-plugin v0.32.3
 
-replace k8s.io/sample-cli-plugin => k8s.io/sample-cli-plugin v0.22.12
+replace k8s.io/sample-controller => k8s.io/sample-controller v0.32.3
 
-replace k8s.io/sample-controller => k8s.io/sample-controller v0.22.12
+replace extender-scheduler => ../extender-scheduler
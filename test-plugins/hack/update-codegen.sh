@@ -0,0 +1,44 @@
+#!/usr/bin/env bash
+
+# Regenerates deepcopy, clientset, listers and informers of the APIs in this module.
+# Run from the test-plugins directory, code-generator is built from the version in go.mod:
+#   go build -o /tmp/bin/ k8s.io/code-generator/cmd/{deepcopy-gen,client-gen,lister-gen,informer-gen}
+#   ./hack/update-codegen.sh
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+BIN=${CODEGEN_BIN:-/tmp/bin}
+MODULE=test-plugins
+BOILERPLATE=hack/boilerplate.go.txt
+
+"${BIN}/deepcopy-gen" \
+  --output-file zz_generated.deepcopy.go \
+  --go-header-file ${BOILERPLATE} \
+  ./apis/sticky/v1alpha1 ./apis/quota/v1alpha1 ./apis/config/v1beta2
+
+# 生成前清掉旧的 client，避免留下已经不再生成的文件
+rm -rf generated
+
+"${BIN}/client-gen" \
+  --clientset-name versioned --input-base "" \
+  --input ${MODULE}/apis/sticky/v1alpha1 \
+  --input ${MODULE}/apis/quota/v1alpha1 \
+  --output-dir generated/clientset \
+  --output-pkg ${MODULE}/generated/clientset \
+  --go-header-file ${BOILERPLATE}
+
+"${BIN}/lister-gen" \
+  --output-dir generated/listers \
+  --output-pkg ${MODULE}/generated/listers \
+  --go-header-file ${BOILERPLATE} \
+  ./apis/sticky/v1alpha1 ./apis/quota/v1alpha1
+
+"${BIN}/informer-gen" \
+  --versioned-clientset-package ${MODULE}/generated/clientset/versioned \
+  --listers-package ${MODULE}/generated/listers \
+  --output-dir generated/informers \
+  --output-pkg ${MODULE}/generated/informers \
+  --go-header-file ${BOILERPLATE} \
+  ./apis/sticky/v1alpha1 ./apis/quota/v1alpha1
@@ -7,6 +7,9 @@ import (
 	_ "k8s.io/component-base/metrics/prometheus/clientgo" // for rest client metric registration
 	_ "k8s.io/component-base/metrics/prometheus/version"  // for version metric registration
 	"k8s.io/kubernetes/cmd/kube-scheduler/app"
+	"test-plugins/generate"
+	"test-plugins/plugins/capacity"
+	"test-plugins/plugins/gang"
 	"test-plugins/plugins/sticky"
 )
 
@@ -21,7 +24,10 @@ func main() {
 		// WithPlugin creates an Option based on plugin name and factory. Please don't remove this function: it is used to register out-of-tree plugins,
 		// hence there are no references to it from the kubernetes scheduler code base.
 		app.WithPlugin(sticky.Name, sticky.NewPlugin),
+		app.WithPlugin(gang.Name, gang.NewPlugin),
+		app.WithPlugin(capacity.Name, capacity.NewPlugin),
 	)
+	command.AddCommand(generate.NewCommand())
 
 	err := command.Execute()
 	if err != nil {
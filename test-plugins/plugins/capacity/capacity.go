@@ -0,0 +1,148 @@
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+
+	"test-plugins/generated/clientset/versioned"
+	quotainformers "test-plugins/generated/informers/externalversions"
+	quotalisters "test-plugins/generated/listers/quota/v1alpha1"
+)
+
+const (
+	// Name of the plugin used in the plugin registry and configurations.
+	Name     = "CapacityQuota"
+	stateKey = Name + "StateKey"
+
+	// quotaSyncTimeout is how long New waits for the ElasticQuota informer,
+	// it doesn't sync at all when the CRD is not installed.
+	quotaSyncTimeout = 30 * time.Second
+)
+
+var (
+	_ framework.PreFilterPlugin  = &CapacityQuota{}
+	_ framework.PostFilterPlugin = &CapacityQuota{}
+
+	_ framework.EnqueueExtensions = &CapacityQuota{}
+	_ preemption.Interface        = &CapacityQuota{}
+)
+
+// CapacityQuota enforces the ElasticQuota of the namespaces: a namespace gets its min, and may
+// borrow the min the other namespaces don't use up to its max. The capacity outside the min of
+// all namespaces is not lent, so whatever is borrowed can be reclaimed: when a namespace within
+// its min doesn't fit, PostFilter preempts the pods of the namespaces using more than their min.
+// It replaces DefaultPreemption, which would preempt by priority only, across the quotas; the
+// profile must disable DefaultPreemption, generate-config --capacity-quota does.
+type CapacityQuota struct {
+	Handler     framework.Handle
+	quotaLister quotalisters.ElasticQuotaLister
+	evaluator   *preemption.Evaluator
+}
+
+// Name returns name of the plugin
+func (pl *CapacityQuota) Name() string {
+	return Name
+}
+
+// NewPlugin initializes the CapacityQuota plugin with an ElasticQuota client built from the
+// scheduler kubeconfig. The plugin has no args.
+func NewPlugin(ctx context.Context, _ runtime.Object, handler framework.Handle) (framework.Plugin, error) {
+	client, err := versioned.NewForConfig(handler.KubeConfig())
+	if err != nil {
+		return nil, fmt.Errorf("create ElasticQuota client: %w", err)
+	}
+	return New(ctx, handler, client)
+}
+
+// New initializes the CapacityQuota plugin with the ElasticQuota client and waits for the
+// ElasticQuota informer to sync.
+func New(ctx context.Context, handler framework.Handle, client versioned.Interface) (framework.Plugin, error) {
+	logger := klog.FromContext(ctx).WithValues("plugin", Name)
+	logger.Info("Initializing CapacityQuota scheduling plugin")
+
+	factory := quotainformers.NewSharedInformerFactory(client, 0)
+	informer := factory.Quota().V1alpha1().ElasticQuotas()
+	pl := &CapacityQuota{Handler: handler, quotaLister: informer.Lister()}
+	pl.evaluator = preemption.NewEvaluator(Name, handler, pl, false)
+	// NewEvaluator 总是填 DefaultPreemption，event 和日志里要写本插件
+	pl.evaluator.PluginName = Name
+
+	syncCtx, cancel := context.WithTimeout(ctx, quotaSyncTimeout)
+	defer cancel()
+	// informer 跟着 scheduler 的 ctx 一起停止
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.Informer().HasSynced) {
+		return nil, fmt.Errorf("ElasticQuota informer not synced in %v, is the CRD installed?", quotaSyncTimeout)
+	}
+	logger.Info("ElasticQuota informer synced")
+	return pl, nil
+}
+
+func getQuotaState(state *framework.CycleState) (*quotaState, error) {
+	c, err := state.Read(stateKey)
+	if err != nil {
+		return nil, fmt.Errorf("read %q from cycleState: %w", stateKey, err)
+	}
+	s, ok := c.(*quotaState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to capacity.quotaState error", c)
+	}
+	return s, nil
+}
+
+// PreFilter invoked at the preFilter extension point.
+// The pod is rejected when its namespace would go over its max, or over its min with no unused
+// min of the other namespaces left to borrow.
+func (pl *CapacityQuota) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	eqs, err := pl.quotaLister.List(labels.Everything())
+	if err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("list ElasticQuotas: %w", err))
+	}
+	nodeInfos, err := pl.Handler.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+	s := newQuotaState(pod, eqs, nodeInfos)
+	state.Write(stateKey, s)
+
+	if status := s.admit(pod.Namespace, s.podRequest); !status.IsSuccess() {
+		klog.FromContext(ctx).V(4).Info("PreFilter: "+status.Message(), "pod", klog.KObj(pod))
+		return nil, status
+	}
+	return nil, nil
+}
+
+// PreFilterExtensions returns the AddPod/RemovePod that keep the quota usage in the cycle state
+// right when preemption removes pods and the nominated pods are added.
+func (pl *CapacityQuota) PreFilterExtensions() framework.PreFilterExtensions {
+	return pl
+}
+
+// AddPod adds the request of the pod to the usage of its namespace.
+func (pl *CapacityQuota) AddPod(_ context.Context, state *framework.CycleState, _ *v1.Pod, podInfoToAdd *framework.PodInfo, _ *framework.NodeInfo) *framework.Status {
+	s, err := getQuotaState(state)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.account(podInfoToAdd.Pod, 1)
+	return nil
+}
+
+// RemovePod removes the request of the pod from the usage of its namespace.
+func (pl *CapacityQuota) RemovePod(_ context.Context, state *framework.CycleState, _ *v1.Pod, podInfoToRemove *framework.PodInfo, _ *framework.NodeInfo) *framework.Status {
+	s, err := getQuotaState(state)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.account(podInfoToRemove.Pod, -1)
+	return nil
+}
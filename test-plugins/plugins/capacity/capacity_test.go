@@ -0,0 +1,192 @@
+package capacity
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/kubernetes/pkg/scheduler/backend/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
+	"k8s.io/utils/ptr"
+
+	"test-plugins/apis/quota/v1alpha1"
+	quotalisters "test-plugins/generated/listers/quota/v1alpha1"
+	"test-plugins/test/testutil"
+)
+
+// makeNodeInfo returns the node info of a node running the pods.
+func makeNodeInfo(pods ...*v1.Pod) *framework.NodeInfo {
+	nodeInfo := framework.NewNodeInfo(pods...)
+	nodeInfo.SetNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	return nodeInfo
+}
+
+func TestAdmit(t *testing.T) {
+	quotas := []*v1alpha1.ElasticQuota{testutil.MakeQuota("team-a", "quota", "2", "4"), testutil.MakeQuota("team-b", "quota", "2", "4")}
+	finished := testutil.MakeCPUPod("team-b", "done", "2")
+	finished.Status.Phase = v1.PodSucceeded
+
+	tests := []struct {
+		name    string
+		pod     *v1.Pod
+		running []*v1.Pod
+		wantOK  bool
+	}{
+		{
+			name:   "within min",
+			pod:    testutil.MakeCPUPod("team-a", "p", "2"),
+			wantOK: true,
+		},
+		{
+			name:   "namespace without quota",
+			pod:    testutil.MakeCPUPod("other", "p", "100"),
+			wantOK: true,
+		},
+		{
+			name:   "over max",
+			pod:    testutil.MakeCPUPod("team-a", "p", "5"),
+			wantOK: false,
+		},
+		{
+			name:    "borrow the idle min of the other namespace",
+			pod:     testutil.MakeCPUPod("team-a", "p", "1"),
+			running: []*v1.Pod{testutil.MakeCPUPod("team-a", "a-0", "2"), testutil.MakeCPUPod("team-b", "b-0", "1")},
+			wantOK:  true,
+		},
+		{
+			name:    "no idle min to borrow",
+			pod:     testutil.MakeCPUPod("team-a", "p", "1"),
+			running: []*v1.Pod{testutil.MakeCPUPod("team-a", "a-0", "2"), testutil.MakeCPUPod("team-b", "b-0", "2")},
+			wantOK:  false,
+		},
+		{
+			name:    "finished pods don't use the quota",
+			pod:     testutil.MakeCPUPod("team-a", "p", "1"),
+			running: []*v1.Pod{testutil.MakeCPUPod("team-a", "a-0", "2"), finished},
+			wantOK:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newQuotaState(tt.pod, quotas, []*framework.NodeInfo{makeNodeInfo(tt.running...)})
+			status := s.admit(tt.pod.Namespace, s.podRequest)
+			if status.IsSuccess() != tt.wantOK {
+				t.Errorf("admit() = %v, want success %v", status, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewQuotaStateFirstQuotaWins(t *testing.T) {
+	quotas := []*v1alpha1.ElasticQuota{testutil.MakeQuota("team-a", "b", "1", "1"), testutil.MakeQuota("team-a", "a", "2", "4")}
+	s := newQuotaState(testutil.MakeCPUPod("team-a", "p", "1"), quotas, nil)
+	if got := s.quotas["team-a"].max[v1.ResourceCPU]; got != 4000 {
+		t.Errorf("max cpu = %d, want 4000 of quota a", got)
+	}
+}
+
+func TestQuotaStateClone(t *testing.T) {
+	pod := testutil.MakeCPUPod("team-a", "a-0", "1")
+	s := newQuotaState(pod, []*v1alpha1.ElasticQuota{testutil.MakeQuota("team-a", "quota", "2", "4")}, []*framework.NodeInfo{makeNodeInfo(pod)})
+	c := s.Clone().(*quotaState)
+	c.account(pod, -1)
+	if got := s.quotas["team-a"].used[v1.ResourceCPU]; got != 1000 {
+		t.Errorf("used cpu of the original = %d after changing the clone, want 1000", got)
+	}
+	if got := c.quotas["team-a"].used[v1.ResourceCPU]; got != 0 {
+		t.Errorf("used cpu of the clone = %d, want 0", got)
+	}
+}
+
+func TestBorrowingAndWithinMin(t *testing.T) {
+	q := &quotaInfo{min: resources{v1.ResourceCPU: 2000}, used: resources{v1.ResourceCPU: 3000}}
+	cpu := resources{v1.ResourceCPU: 1000}
+	gpu := resources{"nvidia.com/gpu": 1}
+	if !q.borrowing(cpu) {
+		t.Error("borrowing(cpu) = false, want true")
+	}
+	if q.borrowing(gpu) {
+		t.Error("borrowing(gpu) = true, want false as gpu has no min")
+	}
+	if q.withinMin(cpu) {
+		t.Error("withinMin(cpu) = true, want false")
+	}
+	if !q.withinMin(gpu) {
+		t.Error("withinMin(gpu) = false, want true")
+	}
+}
+
+func TestPodEligibleToPreemptOthers(t *testing.T) {
+	withPriority := func(pod *v1.Pod, priority int32) *v1.Pod {
+		pod.Spec.Priority = ptr.To(priority)
+		return pod
+	}
+	terminating := func(pod *v1.Pod) *v1.Pod {
+		pod.DeletionTimestamp = ptr.To(metav1.Now())
+		return pod
+	}
+	preemptor := withPriority(testutil.MakeCPUPod("team-a", "p", "1"), 10)
+	preemptor.Status.NominatedNodeName = "n1"
+
+	tests := []struct {
+		name     string
+		assigned []*v1.Pod
+		want     bool
+	}{
+		{
+			name: "no terminating pod",
+			assigned: []*v1.Pod{
+				testutil.OnNode(withPriority(testutil.MakeCPUPod("team-b", "v", "1"), 0), "n1"),
+			},
+			want: true,
+		},
+		{
+			name: "lower priority pod terminating",
+			assigned: []*v1.Pod{
+				testutil.OnNode(terminating(withPriority(testutil.MakeCPUPod("team-b", "v", "1"), 0)), "n1"),
+			},
+			want: false,
+		},
+		{
+			// 不是本 pod 抢占的，等它删完没有意义
+			name: "higher priority pod terminating",
+			assigned: []*v1.Pod{
+				testutil.OnNode(terminating(withPriority(testutil.MakeCPUPod("team-b", "v", "1"), 100)), "n1"),
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			nodes := []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "n1"}}}
+			fwk, err := tf.NewFramework(ctx,
+				[]tf.RegisterPluginFunc{
+					tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+					tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+				},
+				"default-scheduler",
+				frameworkruntime.WithSnapshotSharedLister(cache.NewSnapshot(tt.assigned, nodes)),
+			)
+			if err != nil {
+				t.Fatalf("create framework: %v", err)
+			}
+			indexer := toolscache.NewIndexer(toolscache.MetaNamespaceKeyFunc, toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc})
+			if err := indexer.Add(testutil.MakeQuota("team-a", "quota", "2", "4")); err != nil {
+				t.Fatal(err)
+			}
+			pl := &CapacityQuota{Handler: fwk, quotaLister: quotalisters.NewElasticQuotaLister(indexer)}
+
+			got, msg := pl.PodEligibleToPreemptOthers(ctx, preemptor, framework.NewStatus(framework.Unschedulable))
+			if got != tt.want {
+				t.Errorf("PodEligibleToPreemptOthers = %v (%q), want %v", got, msg, tt.want)
+			}
+		})
+	}
+}
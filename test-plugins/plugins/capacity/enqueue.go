@@ -0,0 +1,43 @@
+package capacity
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+)
+
+// elasticQuotaResource is the ElasticQuota CRD as the GVK the scheduler watches with its dynamic informers.
+const elasticQuotaResource framework.EventResource = "elasticquotas.v1alpha1.quota.scheduling.toys.io"
+
+// EventsToRegister returns the events that may make a pod CapacityQuota rejected schedulable:
+// a deleted pod frees the quota of its namespace and the min it lends, and a changed ElasticQuota
+// may raise the limits.
+func (pl *CapacityQuota) EventsToRegister(_ context.Context) ([]framework.ClusterEventWithHint, error) {
+	return []framework.ClusterEventWithHint{
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete},
+			QueueingHintFn: pl.isSchedulableAfterPodDeleted,
+		},
+		{
+			Event: framework.ClusterEvent{Resource: elasticQuotaResource, ActionType: framework.Add | framework.Update},
+		},
+	}, nil
+}
+
+// isSchedulableAfterPodDeleted queues the pod when the deleted pod was counted in a quota.
+func (pl *CapacityQuota) isSchedulableAfterPodDeleted(logger klog.Logger, pod *v1.Pod, oldObj, _ interface{}) (framework.QueueingHint, error) {
+	deletedPod, _, err := util.As[*v1.Pod](oldObj, nil)
+	if err != nil {
+		return framework.Queue, err
+	}
+	eqs, err := pl.quotaLister.ElasticQuotas(deletedPod.Namespace).List(labels.Everything())
+	if err != nil || len(eqs) == 0 {
+		logger.V(5).Info("deleted pod is not in a quota, skip", "pod", klog.KObj(pod), "deletedPod", klog.KObj(deletedPod))
+		return framework.QueueSkip, nil
+	}
+	return framework.Queue, nil
+}
@@ -0,0 +1,157 @@
+package capacity
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/preemption"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+)
+
+const (
+	// minCandidateNodesPercentage and minCandidateNodesAbsolute are the DefaultPreemption defaults
+	// of how many nodes the preemption dry runs on.
+	minCandidateNodesPercentage = 10
+	minCandidateNodesAbsolute   = 100
+)
+
+// PostFilter invoked at the postFilter extension point.
+// A pod within the min of its namespace preempts the pods of the namespaces borrowing capacity,
+// whatever their priority. A pod borrowing capacity only preempts lower priority pods of its
+// own namespace. Pods without ElasticQuota are left to the other PostFilter plugins.
+func (pl *CapacityQuota) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, m framework.NodeToStatusReader) (*framework.PostFilterResult, *framework.Status) {
+	result, status := pl.evaluator.Preempt(ctx, state, pod, m)
+	if msg := status.Message(); len(msg) > 0 {
+		return result, framework.NewStatus(status.Code(), "preemption: "+msg)
+	}
+	return result, status
+}
+
+// GetOffsetAndNumCandidates chooses a random offset and the number of nodes to dry run the preemption on.
+func (pl *CapacityQuota) GetOffsetAndNumCandidates(numNodes int32) (int32, int32) {
+	n := numNodes * minCandidateNodesPercentage / 100
+	n = max(n, minCandidateNodesAbsolute)
+	return rand.Int31n(numNodes), min(n, numNodes)
+}
+
+// CandidatesToVictimsMap builds the map from the candidate nodes to their victims.
+func (pl *CapacityQuota) CandidatesToVictimsMap(candidates []preemption.Candidate) map[string]*extenderv1.Victims {
+	m := make(map[string]*extenderv1.Victims, len(candidates))
+	for _, c := range candidates {
+		m[c.Name()] = c.Victims()
+	}
+	return m
+}
+
+// PodEligibleToPreemptOthers returns false for the pods which must not preempt, or have already
+// preempted pods which are still terminating on their nominated node. Like DefaultPreemption,
+// only the terminating pods of lower priority than the pod are taken as its victims.
+func (pl *CapacityQuota) PodEligibleToPreemptOthers(_ context.Context, pod *v1.Pod, nominatedNodeStatus *framework.Status) (bool, string) {
+	if pod.Spec.PreemptionPolicy != nil && *pod.Spec.PreemptionPolicy == v1.PreemptNever {
+		return false, "not eligible due to preemptionPolicy=Never."
+	}
+	eqs, err := pl.quotaLister.ElasticQuotas(pod.Namespace).List(labels.Everything())
+	if err != nil || len(eqs) == 0 {
+		return false, "no ElasticQuota in the namespace of the pod."
+	}
+
+	if nodeName := pod.Status.NominatedNodeName; nodeName != "" && nominatedNodeStatus.Code() != framework.UnschedulableAndUnresolvable {
+		if nodeInfo, _ := pl.Handler.SnapshotSharedLister().NodeInfos().Get(nodeName); nodeInfo != nil {
+			podPriority := corev1helpers.PodPriority(pod)
+			for _, p := range nodeInfo.Pods {
+				if p.Pod.DeletionTimestamp != nil && corev1helpers.PodPriority(p.Pod) < podPriority {
+					return false, "not eligible due to a terminating pod on the nominated node."
+				}
+			}
+		}
+	}
+	return true, ""
+}
+
+// SelectVictimsOnNode finds the pods of the node to preempt for the pod. Within its min, the
+// pod takes the capacity back from the namespaces over their min, least important pods first,
+// until they are back at their min. Otherwise it preempts lower priority pods of its namespace.
+// The victims the pod fits without are reprieved, most important first. PDBs are not considered.
+func (pl *CapacityQuota) SelectVictimsOnNode(ctx context.Context, state *framework.CycleState, pod *v1.Pod,
+	nodeInfo *framework.NodeInfo, _ []*policy.PodDisruptionBudget) ([]*v1.Pod, int, *framework.Status) {
+	logger := klog.FromContext(ctx)
+	s, err := getQuotaState(state)
+	if err != nil {
+		return nil, 0, framework.AsStatus(err)
+	}
+	q, ok := s.quotas[pod.Namespace]
+	if !ok {
+		return nil, 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, "no ElasticQuota in the namespace of the pod")
+	}
+	reclaim := q.withinMin(s.podRequest)
+
+	removePod := func(pi *framework.PodInfo) error {
+		if err := nodeInfo.RemovePod(logger, pi.Pod); err != nil {
+			return err
+		}
+		return pl.Handler.RunPreFilterExtensionRemovePod(ctx, state, pod, pi, nodeInfo).AsError()
+	}
+	addPod := func(pi *framework.PodInfo) error {
+		nodeInfo.AddPodInfo(pi)
+		return pl.Handler.RunPreFilterExtensionAddPod(ctx, state, pod, pi, nodeInfo).AsError()
+	}
+	fits := func() bool {
+		return s.admit(pod.Namespace, s.podRequest).IsSuccess() && pl.Handler.RunFilterPluginsWithNominatedPods(ctx, state, pod, nodeInfo).IsSuccess()
+	}
+
+	candidates := make([]*framework.PodInfo, len(nodeInfo.Pods))
+	copy(candidates, nodeInfo.Pods)
+	sort.Slice(candidates, func(i, j int) bool { return util.MoreImportantPod(candidates[j].Pod, candidates[i].Pod) })
+	var potentialVictims []*framework.PodInfo
+	for _, pi := range candidates {
+		if reclaim {
+			// 只收回借出去的部分，对方回到 min 就不再抢它的 pod
+			vq, ok := s.quotas[pi.Pod.Namespace]
+			if pi.Pod.Namespace == pod.Namespace || !ok || !vq.borrowing(s.podRequest) {
+				continue
+			}
+		} else if pi.Pod.Namespace != pod.Namespace || corev1helpers.PodPriority(pi.Pod) >= corev1helpers.PodPriority(pod) {
+			continue
+		}
+		if err := removePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+		potentialVictims = append(potentialVictims, pi)
+	}
+	if len(potentialVictims) == 0 {
+		return nil, 0, framework.NewStatus(framework.UnschedulableAndUnresolvable, "no preemption victims found for incoming pod")
+	}
+	if !fits() {
+		return nil, 0, framework.NewStatus(framework.Unschedulable, "pod doesn't fit after preempting the pods of the namespaces over their min")
+	}
+
+	var victims []*v1.Pod
+	for i := len(potentialVictims) - 1; i >= 0; i-- {
+		pi := potentialVictims[i]
+		if err := addPod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+		if fits() {
+			continue
+		}
+		if err := removePod(pi); err != nil {
+			return nil, 0, framework.AsStatus(err)
+		}
+		victims = append(victims, pi.Pod)
+		logger.V(5).Info("Pod is a potential preemption victim on node", "pod", klog.KObj(pi.Pod), "node", klog.KObj(nodeInfo.Node()), "reclaim", reclaim)
+	}
+	return victims, 0, framework.NewStatus(framework.Success)
+}
+
+// OrderedScoreFuncs returns nil, the candidate nodes are chosen by the default score functions.
+func (pl *CapacityQuota) OrderedScoreFuncs(_ context.Context, _ map[string]*extenderv1.Victims) []func(node string) int64 {
+	return nil
+}
@@ -0,0 +1,185 @@
+package capacity
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	resourcehelper "k8s.io/component-helpers/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"test-plugins/apis/quota/v1alpha1"
+)
+
+// resources are amounts by resource name, millicores for cpu and the value for the others.
+type resources map[v1.ResourceName]int64
+
+func newResources(list v1.ResourceList) resources {
+	r := make(resources, len(list))
+	for name, q := range list {
+		if name == v1.ResourceCPU {
+			r[name] = q.MilliValue()
+		} else {
+			r[name] = q.Value()
+		}
+	}
+	return r
+}
+
+// podRequest returns the resources requested by the pod, init containers and overhead included.
+func podRequest(pod *v1.Pod) resources {
+	return newResources(resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{}))
+}
+
+func (r resources) add(o resources) {
+	for name, v := range o {
+		r[name] += v
+	}
+}
+
+func (r resources) sub(o resources) {
+	for name, v := range o {
+		r[name] -= v
+	}
+}
+
+func (r resources) clone() resources {
+	c := make(resources, len(r))
+	for name, v := range r {
+		c[name] = v
+	}
+	return c
+}
+
+// format returns the amount of the resource as a quantity, e.g. 500m or 2Gi.
+func format(name v1.ResourceName, v int64) string {
+	switch name {
+	case v1.ResourceCPU:
+		return resource.NewMilliQuantity(v, resource.DecimalSI).String()
+	case v1.ResourceMemory, v1.ResourceEphemeralStorage:
+		return resource.NewQuantity(v, resource.BinarySI).String()
+	}
+	return resource.NewQuantity(v, resource.DecimalSI).String()
+}
+
+// quotaInfo is the ElasticQuota of a namespace and what its pods use.
+type quotaInfo struct {
+	min resources
+	max resources
+	// used 为 namespace 里已经在节点上（包括 assume 的）pod 的请求总和
+	used resources
+}
+
+// borrowing returns true if the namespace uses more than its min of one of the resources in req.
+func (q *quotaInfo) borrowing(req resources) bool {
+	for name, min := range q.min {
+		if req[name] > 0 && q.used[name] > min {
+			return true
+		}
+	}
+	return false
+}
+
+// withinMin returns true if the namespace stays within its min with req on top of its usage.
+func (q *quotaInfo) withinMin(req resources) bool {
+	for name, min := range q.min {
+		if req[name] > 0 && q.used[name]+req[name] > min {
+			return false
+		}
+	}
+	return true
+}
+
+// quotaState is the quotas of the namespaces as seen by the scheduling cycle of the pod.
+type quotaState struct {
+	// podRequest 为当前 pod 的请求
+	podRequest resources
+	// quotas 按 namespace 索引，抢占模拟时 AddPod/RemovePod 会修改 used
+	quotas map[string]*quotaInfo
+}
+
+// Clone the quotas are modified when preemption removes pods from a copy of the state.
+func (s *quotaState) Clone() framework.StateData {
+	c := &quotaState{podRequest: s.podRequest, quotas: make(map[string]*quotaInfo, len(s.quotas))}
+	for ns, q := range s.quotas {
+		c.quotas[ns] = &quotaInfo{min: q.min, max: q.max, used: q.used.clone()}
+	}
+	return c
+}
+
+// newQuotaState returns the quotas of the namespaces with the usage of the pods on the nodes.
+// A namespace with several ElasticQuotas uses the first one by name.
+func newQuotaState(pod *v1.Pod, eqs []*v1alpha1.ElasticQuota, nodeInfos []*framework.NodeInfo) *quotaState {
+	sort.Slice(eqs, func(i, j int) bool {
+		if eqs[i].Namespace != eqs[j].Namespace {
+			return eqs[i].Namespace < eqs[j].Namespace
+		}
+		return eqs[i].Name < eqs[j].Name
+	})
+	s := &quotaState{podRequest: podRequest(pod), quotas: make(map[string]*quotaInfo)}
+	for _, eq := range eqs {
+		if _, ok := s.quotas[eq.Namespace]; ok {
+			continue
+		}
+		s.quotas[eq.Namespace] = &quotaInfo{min: newResources(eq.Spec.Min), max: newResources(eq.Spec.Max), used: resources{}}
+	}
+	for _, nodeInfo := range nodeInfos {
+		for _, p := range nodeInfo.Pods {
+			s.account(p.Pod, 1)
+		}
+	}
+	return s
+}
+
+// account adds (sign 1) or removes (sign -1) the request of the pod to the usage of its namespace.
+func (s *quotaState) account(pod *v1.Pod, sign int) {
+	q, ok := s.quotas[pod.Namespace]
+	if !ok || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return
+	}
+	if sign > 0 {
+		q.used.add(podRequest(pod))
+	} else {
+		q.used.sub(podRequest(pod))
+	}
+}
+
+// totals returns the sum of the min and of the usage of all the namespaces with a quota.
+func (s *quotaState) totals() (resources, resources) {
+	min, used := resources{}, resources{}
+	for _, q := range s.quotas {
+		min.add(q.min)
+		used.add(q.used)
+	}
+	return min, used
+}
+
+// admit checks the quota of the namespace lets it run a pod requesting req on top of its usage:
+// within max, and what goes beyond min is borrowed from the min the other namespaces don't use.
+// Namespaces without ElasticQuota are not limited.
+func (s *quotaState) admit(namespace string, req resources) *framework.Status {
+	q, ok := s.quotas[namespace]
+	if !ok {
+		return nil
+	}
+	for name, max := range q.max {
+		if req[name] > 0 && q.used[name]+req[name] > max {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("namespace %s would use %s %s, more than its max %s",
+				namespace, format(name, q.used[name]+req[name]), name, format(name, max)))
+		}
+	}
+
+	// 超过 min 的部分是借来的，只能借其他 namespace 还没用上的 min，这样借出去的都能通过抢占收回
+	totalMin, totalUsed := s.totals()
+	for name, min := range q.min {
+		if req[name] == 0 || q.used[name]+req[name] <= min {
+			continue
+		}
+		if idle := totalMin[name] - totalUsed[name]; req[name] > idle {
+			return framework.NewStatus(framework.Unschedulable, fmt.Sprintf("namespace %s is over its min %s %s and only %s of the other namespaces is idle to borrow",
+				namespace, format(name, min), name, format(name, max(idle, 0))))
+		}
+	}
+	return nil
+}
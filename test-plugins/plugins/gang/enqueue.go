@@ -0,0 +1,60 @@
+package gang
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+)
+
+// EventsToRegister returns the events that may make a pod Gang rejected schedulable: a new pod
+// of its group may complete it, capacity freed by a deleted pod or a new node may let a rejected
+// group fit as a whole. The scheduler runs the hints of Pod Add for assigned pods only, the pod
+// event handler activates the group when an unscheduled pod completes it.
+func (pl *Gang) EventsToRegister(_ context.Context) ([]framework.ClusterEventWithHint, error) {
+	return []framework.ClusterEventWithHint{
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Add},
+			QueueingHintFn: pl.isSchedulableAfterPodAdded,
+		},
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete},
+			QueueingHintFn: pl.isSchedulableAfterCapacityChange,
+		},
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeAllocatable},
+			QueueingHintFn: pl.isSchedulableAfterCapacityChange,
+		},
+	}, nil
+}
+
+// isSchedulableAfterCapacityChange queues the pod unless its group still has too few pods,
+// more capacity doesn't help it then.
+func (pl *Gang) isSchedulableAfterCapacityChange(logger klog.Logger, pod *v1.Pod, _, _ interface{}) (framework.QueueingHint, error) {
+	group, err := pl.podGroup(pod)
+	if err != nil || group == nil {
+		return framework.QueueSkip, nil
+	}
+	if s, ok := pl.groups.get(group.namespace, group.name); ok && s.Phase == GroupPending {
+		logger.V(5).Info("pod group has too few pods, skip", "pod", klog.KObj(pod), "group", group)
+		return framework.QueueSkip, nil
+	}
+	return framework.Queue, nil
+}
+
+// isSchedulableAfterPodAdded queues the pod when a pod of its group is added, the group may
+// have enough pods now.
+func (pl *Gang) isSchedulableAfterPodAdded(logger klog.Logger, pod *v1.Pod, _, newObj interface{}) (framework.QueueingHint, error) {
+	_, addedPod, err := util.As[*v1.Pod](nil, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	group, err := pl.podGroup(pod)
+	if err != nil || group == nil || addedPod.UID == pod.UID || !pl.inGroup(addedPod, group) {
+		return framework.QueueSkip, nil
+	}
+	logger.V(5).Info("pod of the same pod group added", "pod", klog.KObj(pod), "addedPod", klog.KObj(addedPod), "group", group)
+	return framework.Queue, nil
+}
@@ -0,0 +1,366 @@
+package gang
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"test-plugins/apis/config/scheme"
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/apis/config/validation"
+)
+
+const (
+	// Name of the plugin used in the plugin registry and configurations.
+	Name     = "Gang"
+	stateKey = Name + "StateKey"
+
+	// Event reasons on the pod.
+	reasonWaiting  = "GangWaiting"
+	reasonRejected = "GangRejected"
+	// reasonPhasePrefix is followed by the GroupPhase the group of the pod entered.
+	reasonPhasePrefix = "PodGroup"
+)
+
+var (
+	_ framework.PreFilterPlugin = &Gang{}
+	_ framework.ReservePlugin   = &Gang{}
+	_ framework.PermitPlugin    = &Gang{}
+
+	_ framework.EnqueueExtensions = &Gang{}
+)
+
+// Gang places the pods of a pod group all or nothing: the pods reserve their nodes and wait at
+// Permit until MinMember pods of the group are placed, then they are bound together. When the
+// group isn't placed within PermitWaitingTimeSeconds the waiting pods are rejected and release
+// their nodes, instead of holding them while the rest of the group stays pending.
+type Gang struct {
+	Handler   framework.Handle
+	args      *configv1beta2.GangArgs
+	podLister corelisters.PodLister
+	// groups 记录每个 pod group 最近一次调度的状态，见 GroupStatus
+	groups *groupTracker
+}
+
+// podGroup is the group of a pod: the pods of the namespace with the same PodGroupLabel value.
+type podGroup struct {
+	namespace string
+	name      string
+	minMember int
+}
+
+func (g *podGroup) String() string {
+	return g.namespace + "/" + g.name
+}
+
+type gangState struct {
+	// group 为 nil 时 pod 不属于任何 pod group，Gang 不处理
+	group *podGroup
+}
+
+// Clone the state is not modified after PreFilter.
+func (s *gangState) Clone() framework.StateData {
+	return s
+}
+
+// Name returns name of the plugin
+func (pl *Gang) Name() string {
+	return Name
+}
+
+// NewPlugin initializes the Gang plugin.
+func NewPlugin(ctx context.Context, obj runtime.Object, handler framework.Handle) (framework.Plugin, error) {
+	logger := klog.FromContext(ctx).WithValues("plugin", Name)
+	logger.Info("Initializing Gang scheduling plugin")
+
+	args, err := DecodeArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Gang args", "podGroupLabel", *args.PodGroupLabel, "minMemberAnnotation", *args.MinMemberAnnotation,
+		"permitWaitingTimeSeconds", *args.PermitWaitingTimeSeconds)
+
+	pl := Gang{
+		Handler:   handler,
+		args:      args,
+		podLister: handler.SharedInformerFactory().Core().V1().Pods().Lister(),
+		groups:    newGroupTracker(),
+	}
+	RegisterMetrics()
+	if _, err := handler.SharedInformerFactory().Core().V1().Pods().Informer().AddEventHandler(pl.podEventHandler(logger)); err != nil {
+		return nil, fmt.Errorf("add pod event handler: %w", err)
+	}
+	return &pl, nil
+}
+
+// DecodeArgs decodes, defaults and validates the pluginConfig args of Gang like NewPlugin does.
+func DecodeArgs(obj runtime.Object) (*configv1beta2.GangArgs, error) {
+	args, err := getArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateGangArgs(field.NewPath("args"), args); err != nil {
+		return nil, fmt.Errorf("invalid %s args: %w", Name, err)
+	}
+	return args, nil
+}
+
+// getArgs decodes the pluginConfig args of Gang and applies the defaults.
+func getArgs(obj runtime.Object) (*configv1beta2.GangArgs, error) {
+	args := &configv1beta2.GangArgs{}
+	switch t := obj.(type) {
+	case nil:
+	case *configv1beta2.GangArgs:
+		args = t.DeepCopy()
+	case *runtime.Unknown:
+		if len(t.Raw) != 0 {
+			if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), t.Raw, args); err != nil {
+				return nil, fmt.Errorf("decode %s args: %w", Name, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("want args of type GangArgs, got %T", obj)
+	}
+	scheme.Scheme.Default(args)
+	return args, nil
+}
+
+// podGroup returns the group of the pod, nil when the pod has no PodGroupLabel.
+func (pl *Gang) podGroup(pod *v1.Pod) (*podGroup, error) {
+	name, ok := pod.Labels[*pl.args.PodGroupLabel]
+	if !ok || name == "" {
+		return nil, nil
+	}
+	value, ok := pod.Annotations[*pl.args.MinMemberAnnotation]
+	if !ok {
+		return nil, fmt.Errorf("pod of pod group %s has no %s annotation", name, *pl.args.MinMemberAnnotation)
+	}
+	minMember, err := strconv.Atoi(value)
+	if err != nil || minMember < 1 {
+		return nil, fmt.Errorf("%s annotation %q is not a positive number", *pl.args.MinMemberAnnotation, value)
+	}
+	return &podGroup{namespace: pod.Namespace, name: name, minMember: minMember}, nil
+}
+
+// inGroup returns true if the pod belongs to the group.
+func (pl *Gang) inGroup(pod *v1.Pod, group *podGroup) bool {
+	return pod.Namespace == group.namespace && pod.Labels[*pl.args.PodGroupLabel] == group.name
+}
+
+// isActive returns false for the pods which are finished or being deleted, they don't count as members.
+func isActive(pod *v1.Pod) bool {
+	return pod.DeletionTimestamp == nil && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed
+}
+
+// members returns the number of active pods of the group.
+func (pl *Gang) members(group *podGroup) (int, error) {
+	pods, err := pl.podLister.Pods(group.namespace).List(labels.SelectorFromSet(labels.Set{*pl.args.PodGroupLabel: group.name}))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, pod := range pods {
+		if isActive(pod) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// PreFilter invoked at the preFilter extension point.
+// A pod group with fewer pods than MinMember can't be placed, its pods don't reserve any node
+// until the missing pods are created.
+func (pl *Gang) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	group, err := pl.podGroup(pod)
+	if err != nil {
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, err.Error())
+	}
+	state.Write(stateKey, &gangState{group: group})
+	if group == nil {
+		return nil, nil
+	}
+
+	n, err := pl.members(group)
+	if err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("list pods of pod group %s: %w", group, err))
+	}
+	status, changed := pl.groups.observe(group, n)
+	pl.recordPhase(pod, status, changed)
+	if n < group.minMember {
+		m := fmt.Sprintf("pod group %s has %d pods, %d required", group, n, group.minMember)
+		klog.FromContext(ctx).V(4).Info(m, "pod", klog.KObj(pod))
+		return nil, framework.NewStatus(framework.UnschedulableAndUnresolvable, m)
+	}
+	return nil, nil
+}
+
+// PreFilterExtensions returns nil, Gang doesn't filter nodes.
+func (pl *Gang) PreFilterExtensions() framework.PreFilterExtensions {
+	return nil
+}
+
+func getGangState(state *framework.CycleState) (*gangState, error) {
+	c, err := state.Read(stateKey)
+	if err != nil {
+		return nil, fmt.Errorf("read %q from cycleState: %w", stateKey, err)
+	}
+	s, ok := c.(*gangState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to gang.gangState error", c)
+	}
+	return s, nil
+}
+
+// placedMembers returns the pods of the group other than pod already on a node: bound, binding
+// or waiting at Permit, the scheduler assumes them on their nodes.
+func (pl *Gang) placedMembers(group *podGroup, pod *v1.Pod) (int, error) {
+	nodeInfos, err := pl.Handler.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, nodeInfo := range nodeInfos {
+		for _, p := range nodeInfo.Pods {
+			if p.Pod.UID != pod.UID && pl.inGroup(p.Pod, group) && isActive(p.Pod) {
+				n++
+			}
+		}
+	}
+	return n, nil
+}
+
+// waitingMembers returns the pods of the group waiting at Permit.
+func (pl *Gang) waitingMembers(group *podGroup) []framework.WaitingPod {
+	var waiting []framework.WaitingPod
+	pl.Handler.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		if pl.inGroup(wp.GetPod(), group) {
+			waiting = append(waiting, wp)
+		}
+	})
+	return waiting
+}
+
+// activateSiblings moves the pending pods of the group back to the active queue once the pod waits
+// at Permit. PreFilter rejected them while the group was incomplete, and the creation of a pending
+// pod doesn't requeue the others.
+func (pl *Gang) activateSiblings(ctx context.Context, state *framework.CycleState, group *podGroup, pod *v1.Pod) {
+	pods, err := pl.podLister.Pods(group.namespace).List(labels.SelectorFromSet(labels.Set{*pl.args.PodGroupLabel: group.name}))
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "List pods of pod group failed", "group", group)
+		return
+	}
+	c, err := state.Read(framework.PodsToActivateKey)
+	if err != nil {
+		return
+	}
+	toActivate, ok := c.(*framework.PodsToActivate)
+	if !ok {
+		return
+	}
+	toActivate.Lock()
+	defer toActivate.Unlock()
+	for _, p := range pods {
+		if p.UID != pod.UID && p.Spec.NodeName == "" && isActive(p) {
+			toActivate.Map[p.Namespace+"/"+p.Name] = p
+		}
+	}
+}
+
+// Reserve does nothing, Gang only needs Unreserve to release the nodes of the whole group.
+func (pl *Gang) Reserve(_ context.Context, _ *framework.CycleState, _ *v1.Pod, _ string) *framework.Status {
+	return nil
+}
+
+// Permit lets the pod bind once MinMember pods of its group, itself included, are placed, and
+// lets the pods of the group waiting for it bind too. Otherwise the pod keeps its node and waits
+// for the rest of the group at most PermitWaitingTimeSeconds.
+func (pl *Gang) Permit(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (*framework.Status, time.Duration) {
+	s, err := getGangState(state)
+	if err != nil {
+		return framework.AsStatus(err), 0
+	}
+	if s.group == nil {
+		return nil, 0
+	}
+	logger := klog.FromContext(ctx)
+
+	placed, err := pl.placedMembers(s.group, pod)
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("count placed pods of pod group %s: %w", s.group, err)), 0
+	}
+	// 加上当前 pod
+	placed++
+	waiting := pl.waitingMembers(s.group)
+	if placed >= s.group.minMember {
+		for _, wp := range waiting {
+			wp.Allow(Name)
+		}
+		status, changed := pl.groups.scheduled(s.group, placed)
+		pl.recordPhase(pod, status, changed)
+		permitResults.WithLabelValues(resultAllowed).Inc()
+		logger.V(4).Info("Permit: pod group placed", "pod", klog.KObj(pod), "node", nodeName, "group", s.group, "placed", placed, "released", len(waiting))
+		return nil, 0
+	}
+
+	pl.activateSiblings(ctx, state, s.group, pod)
+	status, changed := pl.groups.waiting(s.group, placed, len(waiting)+1)
+	pl.recordPhase(pod, status, changed)
+	permitResults.WithLabelValues(resultWaiting).Inc()
+	m := fmt.Sprintf("waiting for %d more pods of pod group %s, %d of %d placed", s.group.minMember-placed, s.group, placed, s.group.minMember)
+	logger.V(4).Info("Permit: "+m, "pod", klog.KObj(pod), "node", nodeName)
+	pl.event(pod, v1.EventTypeNormal, reasonWaiting, m)
+	return framework.NewStatus(framework.Wait, m), time.Duration(*pl.args.PermitWaitingTimeSeconds) * time.Second
+}
+
+// Unreserve is called when the pod timed out at Permit, was rejected by another plugin or failed
+// to bind: the group can't be placed as a whole this time, so the other pods of the group waiting
+// at Permit are rejected and release their nodes to the other pods.
+func (pl *Gang) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	s, err := getGangState(state)
+	if err != nil || s.group == nil {
+		return
+	}
+	msg := fmt.Sprintf("pod %s of pod group %s was rejected", pod.Name, s.group)
+	waiting := pl.waitingMembers(s.group)
+	for _, wp := range waiting {
+		if wp.GetPod().UID != pod.UID {
+			wp.Reject(Name, msg)
+		}
+	}
+	if status, changed := pl.groups.rejected(s.group); changed {
+		permitResults.WithLabelValues(resultRejected).Inc()
+		klog.FromContext(ctx).Info("Unreserve: pod group not placed, released the waiting pods", "pod", klog.KObj(pod), "node", nodeName, "group", s.group, "waiting", len(waiting))
+		pl.recordPhase(pod, status, changed)
+	}
+	pl.event(pod, v1.EventTypeWarning, reasonRejected,
+		fmt.Sprintf("pod group %s was not placed as a whole, the node %s was released", s.group, nodeName))
+}
+
+// recordPhase emits an event on the pod when its group entered a new phase, so the status of
+// the group shows in kubectl describe pod and kubectl get events.
+func (pl *Gang) recordPhase(pod *v1.Pod, status GroupStatus, changed bool) {
+	if !changed {
+		return
+	}
+	eventtype := v1.EventTypeNormal
+	if status.Phase == GroupRejected {
+		eventtype = v1.EventTypeWarning
+	}
+	pl.event(pod, eventtype, reasonPhasePrefix+string(status.Phase), status.String())
+}
+
+// event emits an event on the pod through the framework EventRecorder.
+func (pl *Gang) event(pod *v1.Pod, eventtype, reason, msg string) {
+	if recorder := pl.Handler.EventRecorder(); recorder != nil {
+		recorder.Eventf(pod, nil, eventtype, reason, "Scheduling", "%s", msg)
+	}
+}
@@ -0,0 +1,334 @@
+package gang
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/backend/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	schedulermetrics "k8s.io/kubernetes/pkg/scheduler/metrics"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/test/testutil"
+)
+
+// testFramework is a framework running Gang at PreFilter, Reserve and Permit with the pods in
+// the fake API and the assigned pods in the snapshot, like the scheduler cache assumes them.
+type testFramework struct {
+	framework.Framework
+	gang     *Gang
+	recorder *events.FakeRecorder
+}
+
+func newTestFramework(ctx context.Context, t *testing.T, args *configv1beta2.GangArgs, pods []*v1.Pod, assigned []*v1.Pod) *testFramework {
+	t.Helper()
+	// 框架给 PreFilter 插件打点，指标要先注册
+	schedulermetrics.Register()
+
+	var objs []runtime.Object
+	for _, pod := range pods {
+		objs = append(objs, pod)
+	}
+	client := clientsetfake.NewClientset(objs...)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	nodes := []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "n1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "n2"}},
+	}
+
+	var pl *Gang
+	recorder := events.NewFakeRecorder(100)
+	fwk, err := tf.NewFramework(ctx,
+		[]tf.RegisterPluginFunc{
+			tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+			tf.RegisterPluginAsExtensions(Name, func(ctx context.Context, _ runtime.Object, fh framework.Handle) (framework.Plugin, error) {
+				p, err := NewPlugin(ctx, args, fh)
+				if err == nil {
+					pl = p.(*Gang)
+				}
+				return p, err
+			}, "PreFilter", "Reserve", "Permit"),
+		},
+		"default-scheduler",
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(factory),
+		frameworkruntime.WithSnapshotSharedLister(cache.NewSnapshot(assigned, nodes)),
+		frameworkruntime.WithEventRecorder(recorder),
+		frameworkruntime.WithWaitingPods(frameworkruntime.NewWaitingPodsMap()),
+	)
+	if err != nil {
+		t.Fatalf("create framework: %v", err)
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return &testFramework{Framework: fwk, gang: pl, recorder: recorder}
+}
+
+// phaseEvents drains the events emitted so far and returns the PodGroup phase ones.
+func (f *testFramework) phaseEvents() []string {
+	var got []string
+	for {
+		select {
+		case e := <-f.recorder.Events:
+			if strings.Contains(e, " "+reasonPhasePrefix) {
+				got = append(got, e)
+			}
+		default:
+			return got
+		}
+	}
+}
+
+// permit runs PreFilter and Permit for the pod on the node and returns its cycle state.
+func (f *testFramework) permit(ctx context.Context, t *testing.T, pod *v1.Pod, nodeName string) (*framework.CycleState, *framework.Status) {
+	t.Helper()
+	state := framework.NewCycleState()
+	if _, status, _ := f.RunPreFilterPlugins(ctx, state, pod); !status.IsSuccess() {
+		t.Fatalf("PreFilter of %s: %v", pod.Name, status)
+	}
+	return state, f.RunPermitPlugins(ctx, state, pod, nodeName)
+}
+
+func TestPreFilter(t *testing.T) {
+	noMinMember := testutil.MakeGroupPod("no-min-member", "train", 0)
+	noMinMember.Annotations = nil
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		pods     []*v1.Pod
+		wantCode framework.Code
+	}{
+		{
+			name: "pod without group",
+			pod:  testutil.MakeGroupPod("p", "", 0),
+		},
+		{
+			name:     "no min member annotation",
+			pod:      noMinMember,
+			pods:     []*v1.Pod{noMinMember},
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name:     "too few pods",
+			pod:      testutil.MakeGroupPod("p1", "train", 3),
+			pods:     []*v1.Pod{testutil.MakeGroupPod("p1", "train", 3), testutil.MakeGroupPod("p2", "train", 3), testutil.MakeGroupPod("other", "eval", 3)},
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name: "enough pods, a bound one included",
+			pod:  testutil.MakeGroupPod("p1", "train", 2),
+			pods: []*v1.Pod{testutil.MakeGroupPod("p1", "train", 2), testutil.OnNode(testutil.MakeGroupPod("p2", "train", 2), "n1")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, tt.pods, nil)
+			_, status := f.gang.PreFilter(ctx, framework.NewCycleState(), tt.pod)
+			if status.Code() != tt.wantCode {
+				t.Errorf("PreFilter returned %v, want code %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPermitAllowsWholeGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p1, p2 := testutil.MakeGroupPod("p1", "train", 2), testutil.MakeGroupPod("p2", "train", 2)
+	// p1 在 Permit 等待时 scheduler 已经把它 assume 到 n1 上
+	f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, []*v1.Pod{p1, p2}, []*v1.Pod{testutil.OnNode(p1, "n1")})
+
+	if _, status := f.permit(ctx, t, p1, "n1"); status.Code() != framework.Wait {
+		t.Fatalf("Permit of p1 returned %v, want Wait", status)
+	}
+	if s, _ := f.gang.GroupStatus(testutil.Namespace, "train"); s.Phase != GroupWaiting || s.Placed != 1 || s.Waiting != 1 {
+		t.Errorf("status after p1 = %+v, want Waiting with 1 placed", s)
+	}
+
+	if _, status := f.permit(ctx, t, p2, "n2"); !status.IsSuccess() {
+		t.Fatalf("Permit of p2 returned %v, want Success", status)
+	}
+	if status := f.WaitOnPermit(ctx, p1); !status.IsSuccess() {
+		t.Errorf("p1 not allowed once p2 was placed: %v", status)
+	}
+	if s, _ := f.gang.GroupStatus(testutil.Namespace, "train"); s.Phase != GroupScheduled || s.Placed != 2 {
+		t.Errorf("status after p2 = %+v, want Scheduled with 2 placed", s)
+	}
+}
+
+func TestUnreserveRejectsWaitingPods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p1, p2, p3 := testutil.MakeGroupPod("p1", "train", 3), testutil.MakeGroupPod("p2", "train", 3), testutil.MakeGroupPod("p3", "train", 3)
+	f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, []*v1.Pod{p1, p2, p3}, []*v1.Pod{testutil.OnNode(p1, "n1"), testutil.OnNode(p2, "n2")})
+
+	if _, status := f.permit(ctx, t, p1, "n1"); status.Code() != framework.Wait {
+		t.Fatalf("Permit of p1 returned %v, want Wait", status)
+	}
+	state, status := f.permit(ctx, t, p2, "n2")
+	if status.Code() != framework.Wait {
+		t.Fatalf("Permit of p2 returned %v, want Wait", status)
+	}
+
+	// 模拟 p2 等待超时，Unreserve 把同组还在等的 p1 也拒绝掉
+	f.RejectWaitingPod(p2.UID)
+	if status := f.WaitOnPermit(ctx, p2); status.Code() != framework.Unschedulable {
+		t.Fatalf("p2 returned %v once rejected, want Unschedulable", status)
+	}
+	f.RunReservePluginsUnreserve(ctx, state, p2, "n2")
+	if status := f.WaitOnPermit(ctx, p1); status.Code() != framework.Unschedulable {
+		t.Errorf("p1 returned %v, want Unschedulable once p2 was rejected", status)
+	}
+	if s, _ := f.gang.GroupStatus(testutil.Namespace, "train"); s.Phase != GroupRejected {
+		t.Errorf("status = %+v, want Rejected", s)
+	}
+}
+
+func TestGroupPhaseRetried(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p1, p2 := testutil.MakeGroupPod("p1", "train", 2), testutil.MakeGroupPod("p2", "train", 2)
+	f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, []*v1.Pod{p1, p2}, nil)
+
+	state, status := f.permit(ctx, t, p1, "n1")
+	if status.Code() != framework.Wait {
+		t.Fatalf("Permit of p1 returned %v, want Wait", status)
+	}
+	f.RejectWaitingPod(p1.UID)
+	f.WaitOnPermit(ctx, p1)
+	f.RunReservePluginsUnreserve(ctx, state, p1, "n1")
+	rejected, _ := f.gang.GroupStatus(testutil.Namespace, "train")
+	if rejected.Phase != GroupRejected {
+		t.Fatalf("status after Unreserve = %+v, want Rejected", rejected)
+	}
+
+	// p1 重试时组又回到 Waiting
+	if _, status, _ := f.RunPreFilterPlugins(ctx, framework.NewCycleState(), p1); !status.IsSuccess() {
+		t.Fatalf("PreFilter of p1 returned %v", status)
+	}
+	s, _ := f.gang.GroupStatus(testutil.Namespace, "train")
+	if s.Phase != GroupWaiting || s.LastTransitionTime.Before(rejected.LastTransitionTime) {
+		t.Errorf("status after the retry = %+v, want Waiting since the retry", s)
+	}
+	want := []string{
+		"Normal PodGroupWaiting pod group default/train is Waiting: 2 pods, 0 placed, 0 waiting at Permit, 2 required",
+		"Warning PodGroupRejected pod group default/train is Rejected: 2 pods, 0 placed, 0 waiting at Permit, 2 required",
+		"Normal PodGroupWaiting pod group default/train is Waiting: 2 pods, 0 placed, 0 waiting at Permit, 2 required",
+	}
+	if diff := cmp.Diff(want, f.phaseEvents()); diff != "" {
+		t.Errorf("phase events (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsSchedulableAfterPodAdded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pod := testutil.MakeGroupPod("p1", "train", 2)
+	otherNamespace := testutil.MakeGroupPod("p2", "train", 2)
+	otherNamespace.Namespace = "other"
+	f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, []*v1.Pod{pod}, nil)
+
+	tests := []struct {
+		name  string
+		added *v1.Pod
+		want  framework.QueueingHint
+	}{
+		{name: "pod of the group", added: testutil.MakeGroupPod("p2", "train", 2), want: framework.Queue},
+		{name: "pod of another group", added: testutil.MakeGroupPod("p2", "eval", 2), want: framework.QueueSkip},
+		{name: "pod without group", added: testutil.MakeGroupPod("p2", "", 0), want: framework.QueueSkip},
+		{name: "group of the same name in another namespace", added: otherNamespace, want: framework.QueueSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.gang.isSchedulableAfterPodAdded(klog.FromContext(ctx), pod, nil, tt.added)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isSchedulableAfterPodAdded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeActivator records the pods activated through the framework.
+type fakeActivator struct {
+	framework.PodActivator
+	activated []string
+}
+
+func (a *fakeActivator) Activate(_ klog.Logger, pods map[string]*v1.Pod) {
+	for key := range pods {
+		a.activated = append(a.activated, key)
+	}
+	sort.Strings(a.activated)
+}
+
+func TestActivateCompleteGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		pods []*v1.Pod
+		want []string
+	}{
+		{
+			name: "group still incomplete",
+			pods: []*v1.Pod{testutil.MakeGroupPod("p1", "train", 3), testutil.MakeGroupPod("p2", "train", 3)},
+		},
+		{
+			name: "last pod completes the group",
+			pods: []*v1.Pod{testutil.MakeGroupPod("p1", "train", 3), testutil.MakeGroupPod("p2", "train", 3), testutil.MakeGroupPod("p3", "train", 3)},
+			want: []string{testutil.Namespace + "/p1", testutil.Namespace + "/p2"},
+		},
+		{
+			name: "placed pods are not activated",
+			pods: []*v1.Pod{testutil.OnNode(testutil.MakeGroupPod("p1", "train", 2), "n1"), testutil.MakeGroupPod("p3", "train", 2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			f := newTestFramework(ctx, t, &configv1beta2.GangArgs{}, tt.pods, nil)
+			activator := &fakeActivator{}
+			f.SetPodActivator(activator)
+
+			f.gang.activateCompleteGroup(klog.FromContext(ctx), tt.pods[len(tt.pods)-1])
+			if diff := cmp.Diff(tt.want, activator.activated); diff != "" {
+				t.Errorf("activated pods (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeArgs(t *testing.T) {
+	args, err := DecodeArgs(&runtime.Unknown{Raw: []byte(`{"apiVersion":"kubescheduler.config.k8s.io/v1","kind":"GangArgs","podGroupLabel":"job-name"}`)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *args.PodGroupLabel != "job-name" || *args.MinMemberAnnotation != testutil.MinMemberAnnotation || *args.PermitWaitingTimeSeconds != 60 {
+		t.Errorf("args not decoded and defaulted: %+v", args)
+	}
+
+	for _, raw := range []string{`{"permitWaitingTimeSeconds":0}`, `{"permitWaitingTimeSeconds":901}`, `{"podGroupLabel":""}`} {
+		if _, err := DecodeArgs(&runtime.Unknown{Raw: []byte(raw)}); err == nil {
+			t.Errorf("%s: want error", raw)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package gang
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	schedulermetrics "k8s.io/kubernetes/pkg/scheduler/metrics"
+)
+
+// GroupPhase is where a pod group is in its scheduling.
+type GroupPhase string
+
+const (
+	// GroupPending the group has fewer pods than MinMember, its pods don't reserve nodes.
+	GroupPending GroupPhase = "Pending"
+	// GroupWaiting some pods of the group reserved their nodes and wait at Permit for the others.
+	GroupWaiting GroupPhase = "Waiting"
+	// GroupScheduled MinMember pods of the group were placed and let bind.
+	GroupScheduled GroupPhase = "Scheduled"
+	// GroupRejected the group timed out at Permit or one of its pods failed, the waiting pods
+	// released their nodes and are retried.
+	GroupRejected GroupPhase = "Rejected"
+)
+
+// Permit results, the values of the result label.
+const (
+	resultAllowed  = "allowed"
+	resultWaiting  = "waiting"
+	resultRejected = "rejected"
+)
+
+var (
+	permitResults = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "gang_permit_total",
+			Help:           "Number of Gang Permit results: pods allowed, pods waiting for their group, groups rejected.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"result"})
+
+	podGroups = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "gang_pod_groups",
+			Help:           "Number of pod groups known to Gang by phase.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"phase"})
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the Gang metrics in the legacy registry the kube-scheduler serves on /metrics.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(permitResults, podGroups)
+	})
+}
+
+// GroupStatus is the scheduling status of a pod group as last seen by Gang.
+type GroupStatus struct {
+	Namespace string
+	Name      string
+	MinMember int
+	// Pods is the number of active pods of the group.
+	Pods int
+	// Placed is the number of pods of the group bound or waiting at Permit.
+	Placed int
+	// Waiting is the number of pods of the group waiting at Permit.
+	Waiting int
+	Phase   GroupPhase
+	// LastTransitionTime is when the group entered Phase.
+	LastTransitionTime time.Time
+}
+
+func (s GroupStatus) String() string {
+	return fmt.Sprintf("pod group %s/%s is %s: %d pods, %d placed, %d waiting at Permit, %d required",
+		s.Namespace, s.Name, s.Phase, s.Pods, s.Placed, s.Waiting, s.MinMember)
+}
+
+// groupTracker keeps the status of the pod groups by namespace/name, the podGroups gauge
+// counts them by phase.
+type groupTracker struct {
+	sync.Mutex
+	groups map[string]*GroupStatus
+}
+
+func newGroupTracker() *groupTracker {
+	return &groupTracker{groups: make(map[string]*GroupStatus)}
+}
+
+// update applies fn to the status of the group, created on first use, and returns a copy of
+// the status and true if the phase changed.
+func (t *groupTracker) update(group *podGroup, fn func(s *GroupStatus)) (GroupStatus, bool) {
+	t.Lock()
+	defer t.Unlock()
+	s, ok := t.groups[group.String()]
+	if !ok {
+		s = &GroupStatus{Namespace: group.namespace, Name: group.name}
+		t.groups[group.String()] = s
+	}
+	s.MinMember = group.minMember
+	phase := s.Phase
+	fn(s)
+	if s.Phase == phase {
+		return *s, false
+	}
+	s.LastTransitionTime = time.Now()
+	t.updateGauge()
+	return *s, true
+}
+
+// updateGauge sets the podGroups gauge, the caller holds the lock.
+func (t *groupTracker) updateGauge() {
+	counts := map[GroupPhase]int{GroupPending: 0, GroupWaiting: 0, GroupScheduled: 0, GroupRejected: 0}
+	for _, s := range t.groups {
+		counts[s.Phase]++
+	}
+	for phase, n := range counts {
+		podGroups.WithLabelValues(string(phase)).Set(float64(n))
+	}
+}
+
+// observe records the number of pods of the group seen by PreFilter. A group with enough pods
+// is Waiting from then on: its first pods are being placed, or a Rejected group is retried, or a
+// pod of a Scheduled group is placed again after it failed to bind or was replaced.
+func (t *groupTracker) observe(group *podGroup, pods int) (GroupStatus, bool) {
+	return t.update(group, func(s *GroupStatus) {
+		s.Pods = pods
+		if pods < group.minMember {
+			s.Phase = GroupPending
+			return
+		}
+		// 新一轮调度开始，Permit 会再更新 Placed 和 Waiting
+		s.Phase = GroupWaiting
+	})
+}
+
+// waiting records that the pod waits at Permit.
+func (t *groupTracker) waiting(group *podGroup, placed, waiting int) (GroupStatus, bool) {
+	return t.update(group, func(s *GroupStatus) {
+		s.Placed, s.Waiting = placed, waiting
+		s.Phase = GroupWaiting
+	})
+}
+
+// scheduled records that the group reached MinMember.
+func (t *groupTracker) scheduled(group *podGroup, placed int) (GroupStatus, bool) {
+	return t.update(group, func(s *GroupStatus) {
+		s.Placed, s.Waiting = placed, 0
+		s.Phase = GroupScheduled
+	})
+}
+
+// rejected records that the waiting pods of the group were rejected, true the first time.
+func (t *groupTracker) rejected(group *podGroup) (GroupStatus, bool) {
+	return t.update(group, func(s *GroupStatus) {
+		if s.Phase == GroupScheduled {
+			// 组已经凑齐，只是某个 pod 绑定失败，重试它就行
+			return
+		}
+		s.Placed, s.Waiting = 0, 0
+		s.Phase = GroupRejected
+	})
+}
+
+// forget drops the status of the group.
+func (t *groupTracker) forget(namespace, name string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.groups, namespace+"/"+name)
+	t.updateGauge()
+}
+
+// get returns a copy of the status of the group.
+func (t *groupTracker) get(namespace, name string) (GroupStatus, bool) {
+	t.Lock()
+	defer t.Unlock()
+	s, ok := t.groups[namespace+"/"+name]
+	if !ok {
+		return GroupStatus{}, false
+	}
+	return *s, true
+}
+
+// GroupStatus returns the status of the pod group, false when Gang didn't see any of its pods.
+func (pl *Gang) GroupStatus(namespace, name string) (GroupStatus, bool) {
+	return pl.groups.get(namespace, name)
+}
+
+// GroupStatuses returns the status of all the pod groups, sorted by namespace and name.
+func (pl *Gang) GroupStatuses() []GroupStatus {
+	pl.groups.Lock()
+	defer pl.groups.Unlock()
+	statuses := make([]GroupStatus, 0, len(pl.groups.groups))
+	for _, s := range pl.groups.groups {
+		statuses = append(statuses, *s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Namespace != statuses[j].Namespace {
+			return statuses[i].Namespace < statuses[j].Namespace
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// podEventHandler activates the pods of a group when an unscheduled pod completes it, and forgets
+// the pod groups whose last pod is deleted.
+func (pl *Gang) podEventHandler(logger klog.Logger) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// 启动时 list 到的 pod 本来就会进 activeQ，scheduler 这时也还没有设置 PodActivator
+			if pod, ok := obj.(*v1.Pod); ok && !isInInitialList && pod.Spec.NodeName == "" {
+				pl.activateCompleteGroup(logger, pod)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			pod, ok := obj.(*v1.Pod)
+			if !ok {
+				return
+			}
+			name, ok := pod.Labels[*pl.args.PodGroupLabel]
+			if !ok {
+				return
+			}
+			if n, err := pl.members(&podGroup{namespace: pod.Namespace, name: name}); err == nil && n == 0 {
+				pl.groups.forget(pod.Namespace, name)
+			}
+		},
+	}
+}
+
+// activateCompleteGroup moves the pending pods of the group of the added pod back to the active
+// queue once the group has MinMember pods. PreFilter rejected them as UnschedulableAndUnresolvable,
+// and the scheduler doesn't run the hints of Pod Add for unscheduled pods.
+func (pl *Gang) activateCompleteGroup(logger klog.Logger, added *v1.Pod) {
+	group, err := pl.podGroup(added)
+	if err != nil || group == nil {
+		return
+	}
+	pods, err := pl.podLister.Pods(group.namespace).List(labels.SelectorFromSet(labels.Set{*pl.args.PodGroupLabel: group.name}))
+	if err != nil {
+		logger.Error(err, "List pods of pod group failed", "group", group)
+		return
+	}
+	var members int
+	toActivate := make(map[string]*v1.Pod)
+	for _, p := range pods {
+		if !isActive(p) {
+			continue
+		}
+		members++
+		if p.UID != added.UID && p.Spec.NodeName == "" {
+			toActivate[p.Namespace+"/"+p.Name] = p
+		}
+	}
+	// 凑齐之前每来一个 pod 都激活整组没有意义，只会让组里的 pod 反复 PreFilter 失败
+	if members < group.minMember || len(toActivate) == 0 {
+		return
+	}
+	logger.V(4).Info("pod group has enough pods, activating its pending pods", "group", group, "pod", klog.KObj(added), "pods", len(toActivate))
+	pl.Handler.Activate(logger, toActivate)
+}
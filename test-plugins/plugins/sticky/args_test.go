@@ -0,0 +1,82 @@
+package sticky
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+)
+
+func TestGetArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		obj     runtime.Object
+		wantKey string
+		wantErr bool
+	}{
+		{
+			name:    "nil args are defaulted",
+			obj:     nil,
+			wantKey: "sticky-nodes",
+		},
+		{
+			name:    "v1 apiVersion of current KubeSchedulerConfiguration",
+			obj:     &runtime.Unknown{Raw: []byte(`{"apiVersion":"kubescheduler.config.k8s.io/v1","kind":"StickyPodArgs","annotationKey":"pinned-nodes"}`)},
+			wantKey: "pinned-nodes",
+		},
+		{
+			name:    "v1beta2 apiVersion",
+			obj:     &runtime.Unknown{Raw: []byte(`{"apiVersion":"kubescheduler.config.k8s.io/v1beta2","kind":"StickyPodArgs","annotationKey":"pinned-nodes"}`)},
+			wantKey: "pinned-nodes",
+		},
+		{
+			name:    "typed args",
+			obj:     &configv1beta2.StickyPodArgs{},
+			wantKey: "sticky-nodes",
+		},
+		{
+			name:    "unknown field",
+			obj:     &runtime.Unknown{Raw: []byte(`{"annotationKey":"pinned-nodes","bogus":true}`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := getArgs(tt.obj)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got args %+v", args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *args.AnnotationKey != tt.wantKey {
+				t.Errorf("annotationKey = %q, want %q", *args.AnnotationKey, tt.wantKey)
+			}
+			if *args.BindingHistoryLimit != 10 {
+				t.Errorf("bindingHistoryLimit not defaulted: %d", *args.BindingHistoryLimit)
+			}
+		})
+	}
+}
+
+func TestPreFilterNodeNames(t *testing.T) {
+	s := &stickyState{nodeExists: true, NodeNames: []string{"a", "b"}, nodeSet: sets.New("a")}
+	if got := s.preFilterNodeNames(); got == nil || !got.Has("a") || got.Has("b") {
+		t.Errorf("required node stickiness: got %v, want the available sticky nodes", got)
+	}
+
+	s.preferred = true
+	if got := s.preFilterNodeNames(); got != nil {
+		t.Errorf("preferred stickiness must not narrow the nodes, got %v", got)
+	}
+
+	s = &stickyState{nodeExists: true, topologyKey: "zone", domains: []string{"z1"}}
+	if got := s.preFilterNodeNames(); got != nil {
+		t.Errorf("domain stickiness must not narrow the nodes, got %v", got)
+	}
+}
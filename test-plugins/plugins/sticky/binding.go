@@ -0,0 +1,178 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/apis/sticky/v1alpha1"
+	"test-plugins/generated/clientset/versioned"
+	stickyinformers "test-plugins/generated/informers/externalversions"
+)
+
+// bindingSyncTimeout is how long NewPlugin waits for the StickyBinding informer,
+// it doesn't sync at all when the CRD is not installed.
+const bindingSyncTimeout = 30 * time.Second
+
+// startBindingInformer builds the StickyBinding client from the scheduler kubeconfig
+// and waits for the StickyBinding informer to sync.
+func (pl *StickyPod) startBindingInformer(ctx context.Context, handler framework.Handle) error {
+	client, err := versioned.NewForConfig(handler.KubeConfig())
+	if err != nil {
+		return fmt.Errorf("create StickyBinding client: %w", err)
+	}
+
+	factory := stickyinformers.NewSharedInformerFactory(client, 0)
+	informer := factory.Scheduling().V1alpha1().StickyBindings()
+	pl.bindingClient = client
+	pl.bindingLister = informer.Lister()
+
+	syncCtx, cancel := context.WithTimeout(ctx, bindingSyncTimeout)
+	defer cancel()
+	// informer 跟着 scheduler 的 ctx 一起停止
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.Informer().HasSynced) {
+		return fmt.Errorf("StickyBinding informer not synced in %v, is the CRD installed?", bindingSyncTimeout)
+	}
+	klog.FromContext(ctx).Info("StickyBinding informer synced")
+	return nil
+}
+
+// bindingFor returns the StickyBinding of the pod itself, or else the one of its owner, nil if none.
+func (pl *StickyPod) bindingFor(pod *v1.Pod, ownerKind, ownerName string) (*v1alpha1.StickyBinding, error) {
+	bindings, err := pl.bindingLister.StickyBindings(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var ownerBinding *v1alpha1.StickyBinding
+	for _, b := range bindings {
+		t := b.Spec.Target
+		if t.PodName != "" {
+			if t.PodName == pod.Name {
+				return b, nil
+			}
+			continue
+		}
+		if ownerBinding == nil && t.Kind == ownerKind && t.Name == ownerName {
+			ownerBinding = b
+		}
+	}
+	return ownerBinding, nil
+}
+
+// stateFromBinding fills s with the stickiness of the binding, it returns false when the
+// binding doesn't pin the pod anywhere yet.
+func (pl *StickyPod) stateFromBinding(b *v1alpha1.StickyBinding, s *stickyState) bool {
+	s.binding = b.Name
+	s.topologyKey = b.Spec.TopologyKey
+	if s.topologyKey == "" {
+		s.topologyKey = *pl.args.TopologyKey
+	}
+
+	// binding 来自 informer 缓存，不能修改，复制一份
+	switch {
+	case len(b.Spec.Domains) != 0 && s.topologyKey != "":
+		s.domains = append([]string(nil), b.Spec.Domains...)
+	case len(b.Spec.NodeNames) != 0:
+		s.NodeNames = append([]string(nil), b.Spec.NodeNames...)
+	default:
+		return false
+	}
+	s.nodeExists = true
+
+	mode := configv1beta2.StickyMode(b.Spec.Mode)
+	if mode == "" {
+		mode = *pl.args.DefaultMode
+	}
+	s.preferred = mode == configv1beta2.StickyModePreferred
+	s.ordered = b.Spec.Ordered
+	return true
+}
+
+// recordBinding appends the placement to the history of the StickyBinding of the pod. When the pod
+// has no stickiness yet and RecordOnBind is set, the node (or its domain) is pinned in the spec too,
+// creating the binding of the owner if needed. The binding is left unchanged when the domain of
+// the node is unknown, pinning the node instead would turn the domain stickiness into node stickiness.
+func (pl *StickyPod) recordBinding(ctx context.Context, pod *v1.Pod, r *stickyState, nodeName string) error {
+	logger := klog.FromContext(ctx)
+	record := v1alpha1.StickyBindingRecord{PodName: pod.Name, NodeName: nodeName, Time: metav1.Now()}
+	if r.topologyKey != "" {
+		domain, err := pl.nodeDomain(nodeName, r.topologyKey)
+		if err != nil {
+			return fmt.Errorf("get domain of node %s: %w", nodeName, err)
+		}
+		record.Domain = domain
+	}
+	pin := *pl.args.RecordOnBind && !r.nodeExists && !r.fallback
+	client := pl.bindingClient.SchedulingV1alpha1().StickyBindings(pod.Namespace)
+
+	name := r.binding
+	if name == "" {
+		if !pin {
+			return nil
+		}
+		name = bindingName(r.ownerKind, r.ownerName)
+		b := &v1alpha1.StickyBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pod.Namespace},
+			Spec: v1alpha1.StickyBindingSpec{
+				Target: v1alpha1.StickyTarget{Kind: r.ownerKind, Name: r.ownerName},
+			},
+		}
+		if _, err := client.Create(ctx, b, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		b, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if pin {
+			if r.topologyKey != "" {
+				b.Spec.TopologyKey = r.topologyKey
+				b.Spec.Domains = appendUnique(b.Spec.Domains, record.Domain)
+			} else {
+				b.Spec.NodeNames = appendUnique(b.Spec.NodeNames, nodeName)
+			}
+			logger.Info("PostBind: pinning pod in StickyBinding", "pod", klog.KObj(pod), "node", nodeName, "stickyBinding", name)
+			if b, err = client.Update(ctx, b, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+
+		b.Status.History = append(b.Status.History, record)
+		if limit := int(*pl.args.BindingHistoryLimit); len(b.Status.History) > limit {
+			b.Status.History = b.Status.History[len(b.Status.History)-limit:]
+		}
+		_, err = client.UpdateStatus(ctx, b, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// bindingName is the name of the StickyBinding StickyPod creates for an owner.
+func bindingName(kind, name string) string {
+	return strings.ToLower(kind) + "-" + name
+}
+
+// appendUnique appends v to values if it is not there yet.
+func appendUnique(values []string, v string) []string {
+	for _, existing := range values {
+		if existing == v {
+			return values
+		}
+	}
+	return append(values, v)
+}
@@ -0,0 +1,213 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+
+	"test-plugins/apis/sticky/v1alpha1"
+)
+
+// ownerKindGVKs maps the supported owner kinds to the <resource>.<version>.<group> form
+// the scheduler starts a dynamic informer for.
+var ownerKindGVKs = map[string]framework.EventResource{
+	"StatefulSet": "statefulsets.v1.apps",
+	"ReplicaSet":  "replicasets.v1.apps",
+	"DaemonSet":   "daemonsets.v1.apps",
+	"Job":         "jobs.v1.batch",
+	// core group is empty, the trailing dot keeps the three sections the scheduler expects
+	"ReplicationController": "replicationcontrollers.v1.",
+}
+
+// stickyBindingGVK is the StickyBinding resource in the form of ownerKindGVKs.
+const stickyBindingGVK framework.EventResource = "stickybindings.v1alpha1.scheduling.toys.io"
+
+// EventsToRegister returns the events that may make a pod StickyPod rejected schedulable,
+// so the pod is moved back to the active queue instead of waiting for the periodic flush:
+// a sticky node is added, becomes Ready, is uncordoned or relabeled (topology stickiness),
+// someone edits the sticky annotations of the owner, a StickyBinding is added or edited,
+// a PVC of the pod is bound to a local PV, or a replica leaves a full sticky node.
+// The QueueingHints skip the events unrelated to the pod.
+// The scheduler needs list/watch RBAC on the owner kinds for the owner events.
+func (pl *StickyPod) EventsToRegister(_ context.Context) ([]framework.ClusterEventWithHint, error) {
+	events := []framework.ClusterEventWithHint{
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Node, ActionType: framework.Add | framework.UpdateNodeCondition | framework.UpdateNodeTaint | framework.UpdateNodeLabel},
+			QueueingHintFn: pl.isSchedulableAfterNodeChange,
+		},
+	}
+	for _, kind := range pl.args.SupportedKinds {
+		if gvk, ok := ownerKindGVKs[kind]; ok {
+			events = append(events, framework.ClusterEventWithHint{
+				Event:          framework.ClusterEvent{Resource: gvk, ActionType: framework.Update},
+				QueueingHintFn: pl.isSchedulableAfterOwnerChange,
+			})
+		}
+	}
+	if *pl.args.UseStickyBindings {
+		events = append(events, framework.ClusterEventWithHint{
+			Event:          framework.ClusterEvent{Resource: stickyBindingGVK, ActionType: framework.Add | framework.Update},
+			QueueingHintFn: pl.isSchedulableAfterBindingChange,
+		})
+	}
+	if *pl.args.UseLocalVolumes {
+		events = append(events,
+			framework.ClusterEventWithHint{
+				Event:          framework.ClusterEvent{Resource: framework.PersistentVolumeClaim, ActionType: framework.Add | framework.Update},
+				QueueingHintFn: pl.isSchedulableAfterPVCChange,
+			},
+			framework.ClusterEventWithHint{
+				Event:          framework.ClusterEvent{Resource: framework.PersistentVolume, ActionType: framework.Add | framework.Update},
+				QueueingHintFn: pl.isSchedulableAfterPVChange,
+			})
+	}
+	if *pl.args.MaxReplicasPerNode > 0 {
+		events = append(events, framework.ClusterEventWithHint{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete},
+			QueueingHintFn: pl.isSchedulableAfterPodDelete,
+		})
+	}
+	return events, nil
+}
+
+// isSchedulableAfterNodeChange queues the pod when one of the sticky nodes (or a node of the
+// sticky domains) it waits for is available. Pods StickyPod didn't leave pending in PreFilter
+// were rejected by Filter, they are always queued.
+func (pl *StickyPod) isSchedulableAfterNodeChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	_, node, err := util.As[*v1.Node](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	waiting := pl.pending.get(pod.UID)
+	if waiting == nil {
+		return framework.Queue, nil
+	}
+	if node.Spec.Unschedulable || !isNodeReady(node) {
+		logger.V(5).Info("node is not available, skip", "pod", klog.KObj(pod), "node", klog.KObj(node))
+		return framework.QueueSkip, nil
+	}
+	if len(waiting.domains) != 0 {
+		if _, ok := domainIndex(node, waiting.topologyKey, waiting.domains); ok {
+			return framework.Queue, nil
+		}
+		return framework.QueueSkip, nil
+	}
+	if sets.New(waiting.NodeNames...).Has(node.Name) {
+		logger.V(5).Info("sticky node is available", "pod", klog.KObj(pod), "node", klog.KObj(node))
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// isSchedulableAfterOwnerChange queues the pod when the sticky annotations of its own owner changed.
+func (pl *StickyPod) isSchedulableAfterOwnerChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return framework.QueueSkip, nil
+	}
+	newOwner, err := meta.Accessor(newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	if newOwner.GetUID() != ref.UID {
+		return framework.QueueSkip, nil
+	}
+	oldOwner, err := meta.Accessor(oldObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+
+	for _, key := range []string{*pl.args.AnnotationKey, DomainsAnnotationKey, TopologyKeyAnnotationKey, ModeAnnotationKey, OrderedAnnotationKey} {
+		if oldOwner.GetAnnotations()[key] != newOwner.GetAnnotations()[key] {
+			logger.V(5).Info("sticky annotation of the owner changed", "pod", klog.KObj(pod), "key", key)
+			return framework.Queue, nil
+		}
+	}
+	return framework.QueueSkip, nil
+}
+
+// isSchedulableAfterBindingChange queues the pod when a StickyBinding of the pod or of its owner changed.
+func (pl *StickyPod) isSchedulableAfterBindingChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	u, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return framework.Queue, fmt.Errorf("expected *unstructured.Unstructured, but got %T", newObj)
+	}
+	if u.GetNamespace() != pod.Namespace {
+		return framework.QueueSkip, nil
+	}
+	b := &v1alpha1.StickyBinding{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, b); err != nil {
+		return framework.Queue, err
+	}
+
+	t := b.Spec.Target
+	if t.PodName != "" {
+		if t.PodName == pod.Name {
+			return framework.Queue, nil
+		}
+		return framework.QueueSkip, nil
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == t.Kind && ref.Name == t.Name {
+		logger.V(5).Info("StickyBinding of the owner changed", "pod", klog.KObj(pod), "stickyBinding", b.Name)
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// isSchedulableAfterPVCChange queues the pod when one of its PVCs changed, e.g. was bound.
+func (pl *StickyPod) isSchedulableAfterPVCChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	_, pvc, err := util.As[*v1.PersistentVolumeClaim](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	if pvc.Namespace == pod.Namespace && podClaims(pod).Has(pvc.Name) {
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// isSchedulableAfterPVChange queues the pod when a PV claimed by one of its PVCs changed.
+func (pl *StickyPod) isSchedulableAfterPVChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	_, pv, err := util.As[*v1.PersistentVolume](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	if ref := pv.Spec.ClaimRef; ref != nil && ref.Namespace == pod.Namespace && podClaims(pod).Has(ref.Name) {
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// isSchedulableAfterPodDelete queues the pod when a replica of the same owner is deleted,
+// it may have freed a sticky node for MaxReplicasPerNode.
+func (pl *StickyPod) isSchedulableAfterPodDelete(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	deleted, _, err := util.As[*v1.Pod](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	ref, deletedRef := metav1.GetControllerOf(pod), metav1.GetControllerOf(deleted)
+	if ref != nil && deletedRef != nil && ref.UID == deletedRef.UID {
+		return framework.Queue, nil
+	}
+	return framework.QueueSkip, nil
+}
+
+// podClaims returns the names of the PVCs of the pod.
+func podClaims(pod *v1.Pod) sets.Set[string] {
+	claims := sets.New[string]()
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			claims.Insert(vol.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	return claims
+}
@@ -0,0 +1,126 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+)
+
+const (
+	// eventDedupTTL is how long the same event on the same object is not emitted again,
+	// a pending pod goes through PreFilter on every retry.
+	eventDedupTTL = 10 * time.Minute
+	// eventCacheSize bounds the events remembered for deduplication during large rollouts.
+	eventCacheSize = 4096
+
+	// Event reasons on the pod.
+	reasonPinned      = "StickyPinned"
+	reasonRecorded    = "StickyRecorded"
+	reasonUnavailable = "StickyNodesUnavailable"
+	reasonFallback    = "StickyFallback"
+	// Event reasons on the owner.
+	reasonInvalidAnnotation = "StickyAnnotationInvalid"
+	reasonNodesNotFound     = "StickyNodesNotFound"
+)
+
+// newEventCache returns the cache of the recently emitted events.
+func newEventCache() *cache.LRUExpireCache {
+	return cache.NewLRUExpireCache(eventCacheSize)
+}
+
+// event emits an event on obj through the framework EventRecorder, unless the same one was
+// emitted on obj in the last eventDedupTTL.
+func (pl *StickyPod) event(ctx context.Context, obj runtime.Object, eventtype, reason, action, msg string) {
+	recorder := pl.Handler.EventRecorder()
+	if recorder == nil || obj == nil {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Emit event failed", "reason", reason)
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", accessor.GetUID(), reason, msg)
+	if _, ok := pl.recentEvents.Get(key); ok {
+		return
+	}
+	pl.recentEvents.Add(key, struct{}{}, eventDedupTTL)
+	recorder.Eventf(obj, nil, eventtype, reason, action, "%s", msg)
+}
+
+// checkOwnerAnnotations warns on the owner about sticky annotations StickyPod ignores or
+// reads differently from what the user probably meant.
+func (pl *StickyPod) checkOwnerAnnotations(ctx context.Context, owner runtime.Object, annotations map[string]string) {
+	var problems []string
+	if v, ok := annotations[ModeAnnotationKey]; ok && v != string(configv1beta2.StickyModeRequired) && v != string(configv1beta2.StickyModePreferred) {
+		problems = append(problems, fmt.Sprintf("%s %q is neither required nor preferred, treated as required", ModeAnnotationKey, v))
+	}
+	if v, ok := annotations[OrderedAnnotationKey]; ok && v != "true" && v != "false" {
+		problems = append(problems, fmt.Sprintf("%s %q is neither true nor false, treated as false", OrderedAnnotationKey, v))
+	}
+	for _, key := range []string{*pl.args.AnnotationKey, DomainsAnnotationKey} {
+		v, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		for _, entry := range strings.Split(v, ",") {
+			if entry == "" || strings.TrimSpace(entry) != entry {
+				problems = append(problems, fmt.Sprintf("%s %q has empty entries or spaces around entries", key, v))
+				break
+			}
+		}
+	}
+	if len(problems) != 0 {
+		pl.event(ctx, owner, v1.EventTypeWarning, reasonInvalidAnnotation, "Scheduling", strings.Join(problems, "; "))
+	}
+}
+
+// warnNodesNotFound warns on the owner about the sticky nodes missing from the cluster.
+func (pl *StickyPod) warnNodesNotFound(ctx context.Context, s *stickyState, known []*v1.Node) {
+	if s.owner == nil {
+		return
+	}
+	knownNames := sets.New[string]()
+	for _, node := range known {
+		knownNames.Insert(node.Name)
+	}
+	var missing []string
+	for _, name := range s.NodeNames {
+		if !knownNames.Has(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		pl.event(ctx, s.owner, v1.EventTypeWarning, reasonNodesNotFound, "Scheduling",
+			fmt.Sprintf("sticky nodes %s no longer exist", strings.Join(missing, ",")))
+	}
+}
+
+// pinnedEvent tells on the pod it was bound to one of its sticky nodes or domains,
+// preferred stickiness may have placed it elsewhere.
+func (pl *StickyPod) pinnedEvent(ctx context.Context, pod *v1.Pod, s *stickyState, nodeName string) {
+	if !s.nodeExists {
+		return
+	}
+	nodeInfo, err := pl.Handler.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil || !s.isStickyNode(nodeInfo.Node()) {
+		return
+	}
+	if len(s.domains) != 0 {
+		v := nodeInfo.Node().Labels[s.topologyKey]
+		pl.event(ctx, pod, v1.EventTypeNormal, reasonPinned, "Binding", fmt.Sprintf("pinned to node %s in sticky %s %s", nodeName, s.topologyKey, v))
+		return
+	}
+	pl.event(ctx, pod, v1.EventTypeNormal, reasonPinned, "Binding", fmt.Sprintf("pinned to sticky node %s", nodeName))
+}
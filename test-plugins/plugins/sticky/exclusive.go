@@ -0,0 +1,62 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// exclusive returns true if at most MaxReplicasPerNode replicas of the owner may run on each sticky node.
+func (pl *StickyPod) exclusive(r *stickyState) bool {
+	return *pl.args.MaxReplicasPerNode > 0 && r.nodeExists && r.ownerUID != ""
+}
+
+// isStickyNode returns true if the node is one of the sticky nodes or in a sticky domain.
+func (r *stickyState) isStickyNode(node *v1.Node) bool {
+	if len(r.domains) != 0 {
+		_, ok := domainIndex(node, r.topologyKey, r.domains)
+		return ok
+	}
+	for _, name := range r.NodeNames {
+		if name == node.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// replicasOn returns the number of pods of the owner on the node other than pod. The pods
+// reserving the node are counted too: the scheduler assumes them into the NodeInfo of the next
+// scheduling cycles until they are bound, so two pods of the owner can't both pass Filter on a
+// sticky node with room for one.
+func replicasOn(nodeInfo *framework.NodeInfo, pod *v1.Pod, owner types.UID) int {
+	n := 0
+	for _, p := range nodeInfo.Pods {
+		if p.Pod.UID == pod.UID {
+			continue
+		}
+		if ref := metav1.GetControllerOf(p.Pod); ref != nil && ref.UID == owner {
+			n++
+		}
+	}
+	return n
+}
+
+// filterExclusive rejects a sticky node already holding MaxReplicasPerNode replicas of the owner.
+func (pl *StickyPod) filterExclusive(ctx context.Context, pod *v1.Pod, r *stickyState, nodeInfo *framework.NodeInfo) *framework.Status {
+	if !pl.exclusive(r) || !r.isStickyNode(nodeInfo.Node()) {
+		return nil
+	}
+
+	if n := replicasOn(nodeInfo, pod, r.ownerUID); n >= int(*pl.args.MaxReplicasPerNode) {
+		m := fmt.Sprintf("%s node already has %d replicas of %s %s", nodeInfo.Node().Name, n, r.ownerKind, r.ownerName)
+		klog.FromContext(ctx).V(5).Info(m)
+		return framework.NewStatus(framework.Unschedulable, m)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+)
+
+// checkStickyNodes looks the sticky nodes up in the scheduler snapshot. It returns the
+// available ones, the ones the scheduler still knows (available, cordoned or NotReady)
+// and why each of the others is not available.
+func (pl *StickyPod) checkStickyNodes(nodeNames []string) (available []string, known []*v1.Node, reasons []string) {
+	for _, name := range nodeNames {
+		nodeInfo, err := pl.Handler.SnapshotSharedLister().NodeInfos().Get(name)
+		if err != nil || nodeInfo.Node() == nil {
+			reasons = append(reasons, fmt.Sprintf("%s not found", name))
+			continue
+		}
+
+		node := nodeInfo.Node()
+		known = append(known, node)
+		if node.Spec.Unschedulable {
+			reasons = append(reasons, fmt.Sprintf("%s cordoned", name))
+			continue
+		}
+		if !isNodeReady(node) {
+			reasons = append(reasons, fmt.Sprintf("%s NotReady", name))
+			continue
+		}
+		available = append(available, name)
+	}
+	return
+}
+
+// applyMissingNodesPolicy is called when none of the sticky nodes is available, msg tells why.
+// It updates s according to StickyPodArgs.MissingNodesPolicy, or returns the status the pod waits with.
+// known are the sticky nodes the scheduler still knows, SameTopology falls back to their domains.
+func (pl *StickyPod) applyMissingNodesPolicy(ctx context.Context, pod *v1.Pod, s *stickyState, msg string, known []*v1.Node) *framework.Status {
+	logger := klog.FromContext(ctx)
+	switch *pl.args.MissingNodesPolicy {
+	case configv1beta2.MissingNodesPolicyAnyNode:
+		logger.Info("PreFilter: sticky nodes not available, fall back to any node", "pod", klog.KObj(pod), "reason", msg)
+		pl.event(ctx, pod, v1.EventTypeWarning, reasonFallback, "Scheduling", msg+", fall back to any node")
+		s.nodeExists = false
+		s.fallback = true
+		return nil
+
+	case configv1beta2.MissingNodesPolicySameTopology:
+		key := *pl.args.FallbackTopologyKey
+		domains := sets.New[string]()
+		for _, node := range known {
+			if v, ok := node.Labels[key]; ok {
+				domains.Insert(v)
+			}
+		}
+		if domains.Len() == 0 {
+			pl.event(ctx, pod, v1.EventTypeWarning, reasonUnavailable, "Scheduling", msg)
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("%s, no %s known to fall back to", msg, key))
+		}
+		logger.Info("PreFilter: sticky nodes not available, fall back to their topology domains", "pod", klog.KObj(pod), "reason", msg, "key", key, "domains", sets.List(domains))
+		pl.event(ctx, pod, v1.EventTypeWarning, reasonFallback, "Scheduling", fmt.Sprintf("%s, fall back to nodes with %s in %v", msg, key, sets.List(domains)))
+		s.nodeExists = false
+		s.fallback = true
+		s.fallbackDomains = domains
+		return nil
+
+	default:
+		timeout := time.Duration(*pl.args.WaitTimeoutSeconds) * time.Second
+		if timeout > 0 && time.Since(pendingSince(pod)) > timeout {
+			logger.Info("PreFilter: sticky nodes not available for too long, fall back to any node", "pod", klog.KObj(pod), "reason", msg, "timeout", timeout)
+			pl.event(ctx, pod, v1.EventTypeWarning, reasonFallback, "Scheduling", fmt.Sprintf("%s, waited more than %v, fall back to any node", msg, timeout))
+			s.nodeExists = false
+			s.fallback = true
+			return nil
+		}
+		pl.event(ctx, pod, v1.EventTypeWarning, reasonUnavailable, "Scheduling", msg)
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, msg)
+	}
+}
+
+// filterFallbackDomain only lets the nodes in the fallback topology domains pass.
+func (pl *StickyPod) filterFallbackDomain(ctx context.Context, nodeInfo *framework.NodeInfo, r *stickyState) *framework.Status {
+	key := *pl.args.FallbackTopologyKey
+	if v, ok := nodeInfo.Node().Labels[key]; ok && r.fallbackDomains.Has(v) {
+		return nil
+	}
+	m := fmt.Sprintf("%s node not in sticky %s %v", nodeInfo.Node().Name, key, sets.List(r.fallbackDomains))
+	klog.FromContext(ctx).V(5).Info(m)
+	return framework.NewStatus(framework.Unschedulable, m)
+}
+
+// isNodeReady returns true if the node has the Ready condition set to True.
+func isNodeReady(node *v1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// pendingSince returns when the pod was first found unschedulable, or when it was created
+// if the scheduler hasn't failed it yet.
+func pendingSince(pod *v1.Pod) time.Time {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && !c.LastTransitionTime.IsZero() {
+			return c.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
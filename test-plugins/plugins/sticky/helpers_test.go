@@ -0,0 +1,85 @@
+package sticky
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler/backend/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/test/testutil"
+)
+
+// testArgs returns the StickyPod args with all owner kinds enabled, edited by fns.
+func testArgs(fns ...func(*configv1beta2.StickyPodArgs)) *configv1beta2.StickyPodArgs {
+	args := &configv1beta2.StickyPodArgs{SupportedKinds: testutil.AllKinds}
+	for _, fn := range fns {
+		fn(args)
+	}
+	return args
+}
+
+// testPlugin is a StickyPod built on a framework with fake clients and an in-memory snapshot.
+type testPlugin struct {
+	*StickyPod
+	client   *clientsetfake.Clientset
+	snapshot *cache.Snapshot
+	recorder *events.FakeRecorder
+}
+
+// newTestPlugin builds the plugin with the nodes and the assigned pods in the snapshot
+// and the objects (owners) in the fake API.
+func newTestPlugin(ctx context.Context, t *testing.T, args *configv1beta2.StickyPodArgs, nodes []*v1.Node, pods []*v1.Pod, objs ...runtime.Object) *testPlugin {
+	t.Helper()
+
+	client := clientsetfake.NewClientset(objs...)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	snapshot := cache.NewSnapshot(pods, nodes)
+	recorder := events.NewFakeRecorder(100)
+
+	fh, err := tf.NewFramework(ctx,
+		[]tf.RegisterPluginFunc{
+			tf.RegisterQueueSortPlugin(queuesort.Name, queuesort.New),
+			tf.RegisterBindPlugin(defaultbinder.Name, defaultbinder.New),
+		},
+		"default-scheduler",
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(factory),
+		frameworkruntime.WithSnapshotSharedLister(snapshot),
+		frameworkruntime.WithEventRecorder(recorder),
+	)
+	if err != nil {
+		t.Fatalf("create framework: %v", err)
+	}
+
+	pl, err := NewPlugin(ctx, args, fh)
+	if err != nil {
+		t.Fatalf("create plugin: %v", err)
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	return &testPlugin{StickyPod: pl.(*StickyPod), client: client, snapshot: snapshot, recorder: recorder}
+}
+
+// recordedEvents drains the events emitted so far.
+func (p *testPlugin) recordedEvents() []string {
+	var got []string
+	for {
+		select {
+		case e := <-p.recorder.Events:
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// localVolumes are the bound local PVs of a pod and the nodes they can be used on.
+type localVolumes struct {
+	// names of the PVs
+	names []string
+	// nodes every PV can be used on, nil when the pod has no bound local PV
+	nodes []string
+	// missing names the nodes of the PVs none of whose nodes is in the snapshot anymore
+	missing []string
+}
+
+// localVolumeNodes returns the bound local PVs of the pod; unbound claims (WaitForFirstConsumer)
+// don't pin the pod yet and are left to VolumeBinding.
+func (pl *StickyPod) localVolumeNodes(pod *v1.Pod) (*localVolumes, error) {
+	lv := &localVolumes{}
+	var nodes sets.Set[string]
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := pl.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(vol.PersistentVolumeClaim.ClaimName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := pl.pvLister.Get(pvc.Spec.VolumeName)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pv.Spec.Local == nil || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			continue
+		}
+
+		pvNodes, err := pl.matchingNodes(pv.Spec.NodeAffinity.Required)
+		if err != nil {
+			return nil, fmt.Errorf("node affinity of PV %s: %w", pv.Name, err)
+		}
+		if pvNodes.Len() == 0 {
+			// PV 所在的节点已经不在集群里了，和 sticky node 不可用一样处理
+			lv.missing = append(lv.missing, affinityNodes(pv.Spec.NodeAffinity.Required)...)
+		}
+		// 多个 local PV 时 pod 只能去它们共同的节点
+		if nodes == nil {
+			nodes = pvNodes
+		} else {
+			nodes = nodes.Intersection(pvNodes)
+		}
+		lv.names = append(lv.names, pv.Name)
+	}
+	if nodes != nil {
+		lv.nodes = sets.List(nodes)
+	}
+	return lv, nil
+}
+
+// affinityNodes names the nodes the PV node affinity selects by hostname, or describes the
+// affinity when it selects nodes by other labels.
+func affinityNodes(required *v1.NodeSelector) []string {
+	var names, exprs []string
+	for _, term := range required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == v1.LabelHostname && expr.Operator == v1.NodeSelectorOpIn {
+				names = append(names, expr.Values...)
+				continue
+			}
+			exprs = append(exprs, fmt.Sprintf("%s %s %v", expr.Key, expr.Operator, expr.Values))
+		}
+	}
+	if len(names) == 0 {
+		return exprs
+	}
+	return names
+}
+
+// matchingNodes returns the nodes of the snapshot matching the PV node affinity.
+func (pl *StickyPod) matchingNodes(required *v1.NodeSelector) (sets.Set[string], error) {
+	selector, err := nodeaffinity.NewNodeSelector(required)
+	if err != nil {
+		return nil, err
+	}
+	nodeInfos, err := pl.Handler.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return nil, err
+	}
+	nodes := sets.New[string]()
+	for _, nodeInfo := range nodeInfos {
+		if node := nodeInfo.Node(); node != nil && selector.Match(node) {
+			nodes.Insert(node.Name)
+		}
+	}
+	return nodes, nil
+}
+
+// localVolumesMissing applies MissingNodesPolicy to a pod whose local PVs are on nodes which left
+// the cluster, the pod waits for them like for missing sticky nodes.
+func (pl *StickyPod) localVolumesMissing(ctx context.Context, pod *v1.Pod, s *stickyState, lv *localVolumes) *framework.Status {
+	msg := fmt.Sprintf("nodes %s of local PV %s are not available: not found", strings.Join(lv.missing, ","), strings.Join(lv.names, ","))
+	if status := pl.applyMissingNodesPolicy(ctx, pod, s, msg, nil); status != nil {
+		return status
+	}
+	return framework.NewStatus(framework.Success, "Check pod finish, return")
+}
+
+// mergeLocalVolumeNodes narrows the sticky nodes or domains in s down to the nodes of the bound
+// local PVs of the pod, and makes them required since the data is on those nodes. When the
+// stickiness and the PVs have no node in common the pod can't run anywhere, it gets an
+// UnschedulableAndUnresolvable status naming both. PVs on nodes which left the cluster go through
+// MissingNodesPolicy. A non-nil status ends PreFilter.
+func (pl *StickyPod) mergeLocalVolumeNodes(ctx context.Context, pod *v1.Pod, s *stickyState) *framework.Status {
+	if pl.pvLister == nil {
+		return nil
+	}
+	lv, err := pl.localVolumeNodes(pod)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "PreFilter: get local PVs failed", "pod", klog.KObj(pod))
+		return framework.NewStatus(framework.Error, "get local PVs failed")
+	}
+	if lv.nodes == nil {
+		return nil
+	}
+	// 数据在本地盘上，preferred 也只能去这些节点
+	s.preferred = false
+	if len(lv.missing) != 0 {
+		return pl.localVolumesMissing(ctx, pod, s, lv)
+	}
+
+	if len(s.domains) != 0 {
+		pvDomains := sets.New[string]()
+		var reasons []string
+		for _, name := range lv.nodes {
+			domain, err := pl.nodeDomain(name, s.topologyKey)
+			if err != nil {
+				reasons = append(reasons, err.Error())
+				continue
+			}
+			pvDomains.Insert(domain)
+		}
+		// 保留 domains 原来的顺序，ordered 打分还要用
+		var merged []string
+		for _, domain := range s.domains {
+			if pvDomains.Has(domain) {
+				merged = append(merged, domain)
+			}
+		}
+		if len(merged) == 0 {
+			s.volumeConflict = true
+			msg := fmt.Sprintf("local PV %s is on nodes %v, not in sticky %s %s",
+				strings.Join(lv.names, ","), lv.nodes, s.topologyKey, strings.Join(s.domains, ","))
+			if len(reasons) != 0 {
+				msg += ": " + strings.Join(reasons, ", ")
+			}
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, msg)
+		}
+		klog.FromContext(ctx).Info("PreFilter: sticky domains narrowed by local PVs", "pod", klog.KObj(pod), "domains", merged, "pvs", lv.names)
+		s.domains = merged
+		return nil
+	}
+
+	// 保留 sticky nodes 原来的顺序，ordered 打分还要用
+	pvNodeSet := sets.New(lv.nodes...)
+	var merged []string
+	for _, name := range s.NodeNames {
+		if pvNodeSet.Has(name) {
+			merged = append(merged, name)
+		}
+	}
+	if len(merged) == 0 {
+		s.volumeConflict = true
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("local PV %s is on nodes %v, not in sticky nodes %s",
+			strings.Join(lv.names, ","), lv.nodes, strings.Join(s.NodeNames, ",")))
+	}
+	klog.FromContext(ctx).Info("PreFilter: sticky nodes narrowed by local PVs", "pod", klog.KObj(pod), "nodes", merged, "pvs", lv.names)
+	s.NodeNames = merged
+	return nil
+}
+
+// stickToLocalVolumes makes the nodes of the bound local PVs the sticky nodes of a pod
+// whose owner has no stickiness of its own.
+func (pl *StickyPod) stickToLocalVolumes(ctx context.Context, pod *v1.Pod, s *stickyState) *framework.Status {
+	if pl.pvLister == nil {
+		return framework.NewStatus(framework.Success, "Pod don't stick nodes ")
+	}
+	lv, err := pl.localVolumeNodes(pod)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "PreFilter: get local PVs failed", "pod", klog.KObj(pod))
+		return framework.NewStatus(framework.Error, "get local PVs failed")
+	}
+	if lv.nodes == nil {
+		return framework.NewStatus(framework.Success, "Pod don't stick nodes ")
+	}
+
+	s.topologyKey = ""
+	s.nodeExists = true
+	s.preferred = false
+	if len(lv.missing) != 0 {
+		s.NodeNames = lv.missing
+		return pl.localVolumesMissing(ctx, pod, s, lv)
+	}
+	if len(lv.nodes) == 0 {
+		s.volumeConflict = true
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, fmt.Sprintf("local PV %s have no node in common", strings.Join(lv.names, ",")))
+	}
+
+	klog.FromContext(ctx).Info("PreFilter: pod sticks to the nodes of its local PVs", "pod", klog.KObj(pod), "nodes", lv.nodes, "pvs", lv.names)
+	s.NodeNames = lv.nodes
+	return pl.checkAvailability(ctx, pod, s)
+}
@@ -0,0 +1,163 @@
+package sticky
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/ptr"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/test/testutil"
+)
+
+// makeLocalPV returns a local PV on the node bound to the claim of the same name.
+func makeLocalPV(name, nodeName string) (*v1.PersistentVolume, *v1.PersistentVolumeClaim) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{Local: &v1.LocalVolumeSource{Path: "/data"}},
+			NodeAffinity: &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchExpressions: []v1.NodeSelectorRequirement{{Key: v1.LabelHostname, Operator: v1.NodeSelectorOpIn, Values: []string{nodeName}}},
+			}}}},
+		},
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testutil.Namespace},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: name},
+	}
+	return pv, pvc
+}
+
+// withClaim mounts the claim in the pod.
+func withClaim(pod *v1.Pod, claim string) *v1.Pod {
+	pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+		Name:         claim,
+		VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+	})
+	return pod
+}
+
+func TestLocalVolumes(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        *configv1beta2.StickyPodArgs
+		annotations map[string]string
+		pvNode      string
+		wantCode    framework.Code
+		wantMessage string
+		wantResult  []string
+		// wantDomains are the sticky domains left after the merge.
+		wantDomains []string
+		wantPending bool
+	}{
+		{
+			name:       "owner without stickiness sticks to the PV node",
+			pvNode:     "n2",
+			wantCode:   framework.Success,
+			wantResult: []string{"n2"},
+		},
+		{
+			name:        "sticky nodes narrowed to the PV node",
+			annotations: map[string]string{"sticky-nodes": "n1,n2", ModeAnnotationKey: "preferred"},
+			pvNode:      "n2",
+			wantCode:    framework.Success,
+			wantResult:  []string{"n2"},
+		},
+		{
+			name:        "PV not on the sticky nodes",
+			annotations: map[string]string{"sticky-nodes": "n1"},
+			pvNode:      "n3",
+			wantCode:    framework.UnschedulableAndUnresolvable,
+			wantMessage: "local PV pv-0 is on nodes [n3], not in sticky nodes n1",
+		},
+		{
+			name:        "sticky domains narrowed to the domain of the PV node",
+			annotations: map[string]string{TopologyKeyAnnotationKey: testutil.ZoneKey, DomainsAnnotationKey: "z1,z2", ModeAnnotationKey: "preferred"},
+			pvNode:      "n3",
+			wantCode:    framework.Success,
+			wantDomains: []string{"z2"},
+		},
+		{
+			name:        "PV node without the topology label",
+			annotations: map[string]string{TopologyKeyAnnotationKey: testutil.ZoneKey, DomainsAnnotationKey: "z1"},
+			pvNode:      "n4",
+			wantCode:    framework.UnschedulableAndUnresolvable,
+			wantDomains: []string{"z1"},
+			wantMessage: "local PV pv-0 is on nodes [n4], not in sticky zone z1: node n4 has no label zone",
+		},
+		{
+			name:        "PV node left the cluster",
+			pvNode:      "n9",
+			wantCode:    framework.UnschedulableAndUnresolvable,
+			wantMessage: "nodes n9 of local PV pv-0 are not available: not found",
+			wantPending: true,
+		},
+		{
+			name:        "PV node of the sticky nodes left the cluster",
+			annotations: map[string]string{"sticky-nodes": "n1,n9"},
+			pvNode:      "n9",
+			wantCode:    framework.UnschedulableAndUnresolvable,
+			wantMessage: "nodes n9 of local PV pv-0 are not available: not found",
+			wantPending: true,
+		},
+		{
+			name: "PV node left the cluster, fall back to any node",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.UseLocalVolumes = ptr.To(true)
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicyAnyNode)
+			}),
+			pvNode:   "n9",
+			wantCode: framework.Skip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			args := tt.args
+			if args == nil {
+				args = testArgs(func(args *configv1beta2.StickyPodArgs) {
+					args.UseLocalVolumes = ptr.To(true)
+				})
+			}
+			pv, pvc := makeLocalPV("pv-0", tt.pvNode)
+			nodes := append(defaultNodes(), testutil.MakeNode("n4", ""))
+			pl := newTestPlugin(ctx, t, args, nodes, nil,
+				testutil.MakeOwner("StatefulSet", "db", tt.annotations), pv, pvc)
+			pod := withClaim(testutil.MakePod("db-0", "StatefulSet", "db"), "pv-0")
+
+			state := framework.NewCycleState()
+			result, status := pl.PreFilter(ctx, state, pod)
+			if status.Code() != tt.wantCode {
+				t.Fatalf("PreFilter status = %v, want %v", status, tt.wantCode)
+			}
+			if tt.wantMessage != "" && status.Message() != tt.wantMessage {
+				t.Errorf("PreFilter message = %q, want %q", status.Message(), tt.wantMessage)
+			}
+			var gotResult []string
+			if result != nil {
+				gotResult = sets.List(result.NodeNames)
+			}
+			if diff := cmp.Diff(tt.wantResult, gotResult); diff != "" {
+				t.Errorf("PreFilterResult (-want +got):\n%s", diff)
+			}
+			s, err := getStickyState(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantDomains, s.domains); diff != "" {
+				t.Errorf("sticky domains (-want +got):\n%s", diff)
+			}
+			if s.preferred {
+				t.Errorf("pod with local PVs still prefers its sticky nodes")
+			}
+			if got := pl.pending.get(pod.UID) != nil; got != tt.wantPending {
+				t.Errorf("pending = %v, want %v", got, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package sticky
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	schedulermetrics "k8s.io/kubernetes/pkg/scheduler/metrics"
+)
+
+// PreFilter outcomes, the values of the outcome label.
+const (
+	// outcomeHonored the pod sticks to nodes or domains which are available.
+	outcomeHonored = "sticky_honored"
+	// outcomeNoAnnotation the pod has no stickiness, or no supported owner.
+	outcomeNoAnnotation = "no_annotation"
+	// outcomeOwnerNotFound the owner of the pod is gone.
+	outcomeOwnerNotFound = "owner_not_found"
+	// outcomeStickyNodeMissing none of the sticky nodes is available, the pod stays pending.
+	outcomeStickyNodeMissing = "sticky_node_missing"
+	// outcomeLocalVolumeConflict the bound local PVs of the pod are not on its sticky nodes or
+	// domains, the pod can't run anywhere until either changes, no node coming back helps.
+	outcomeLocalVolumeConflict = "local_volume_conflict"
+	// outcomeFallback none of the sticky nodes is available, MissingNodesPolicy let the pod go elsewhere.
+	outcomeFallback = "fallback"
+	// outcomeError looking the stickiness up failed.
+	outcomeError = "error"
+)
+
+var (
+	prefilterOutcomes = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "sticky_pod_prefilter_total",
+			Help:           "Number of StickyPod PreFilter calls by outcome.",
+			StabilityLevel: metrics.ALPHA,
+		}, []string{"outcome"})
+
+	prefilterDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "sticky_pod_prefilter_duration_seconds",
+			Help:           "Latency of StickyPod PreFilter, including the owner and StickyBinding lookups.",
+			Buckets:        metrics.ExponentialBuckets(0.0001, 2, 15),
+			StabilityLevel: metrics.ALPHA,
+		})
+
+	filterDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "sticky_pod_filter_duration_seconds",
+			Help:           "Latency of StickyPod Filter on one node.",
+			Buckets:        metrics.ExponentialBuckets(0.00001, 2, 12),
+			StabilityLevel: metrics.ALPHA,
+		})
+
+	pendingStickyPods = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulermetrics.SchedulerSubsystem,
+			Name:           "sticky_pod_pending_pods",
+			Help:           "Number of pods pending because none of their sticky nodes is available.",
+			StabilityLevel: metrics.ALPHA,
+		})
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the StickyPod metrics in the legacy registry the kube-scheduler serves on /metrics.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(prefilterOutcomes, prefilterDuration, filterDuration, pendingStickyPods)
+	})
+}
+
+// pendingTracker keeps the pods waiting for their sticky nodes with the stickiness they wait for,
+// the pendingStickyPods gauge is its size and the QueueingHints look the sticky nodes up in it.
+type pendingTracker struct {
+	sync.Mutex
+	pods map[types.UID]*stickyState
+}
+
+func newPendingTracker() *pendingTracker {
+	return &pendingTracker{pods: make(map[types.UID]*stickyState)}
+}
+
+// set records the stickiness the pod is waiting for, nil when it is not waiting.
+func (t *pendingTracker) set(uid types.UID, s *stickyState) {
+	t.Lock()
+	defer t.Unlock()
+	if s != nil {
+		t.pods[uid] = s
+	} else {
+		delete(t.pods, uid)
+	}
+	pendingStickyPods.Set(float64(len(t.pods)))
+}
+
+// get returns the stickiness the pod is waiting for, nil if it is not waiting.
+func (t *pendingTracker) get(uid types.UID) *stickyState {
+	t.Lock()
+	defer t.Unlock()
+	return t.pods[uid]
+}
+
+// podDeleteHandler forgets the pods deleted while waiting for their sticky nodes.
+func (t *pendingTracker) podDeleteHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if pod, ok := obj.(*v1.Pod); ok {
+				t.set(pod.UID, nil)
+			}
+		},
+	}
+}
+
+// recordPreFilter counts the PreFilter outcome of the pod and updates the pending sticky pods.
+// outcome is empty unless PreFilter already knows it, otherwise it's derived from s and status.
+func (pl *StickyPod) recordPreFilter(pod *v1.Pod, s *stickyState, status *framework.Status, outcome string) {
+	if outcome == "" {
+		switch {
+		case s.fallback:
+			outcome = outcomeFallback
+		case s.volumeConflict:
+			outcome = outcomeLocalVolumeConflict
+		case status.Code() == framework.Error:
+			outcome = outcomeError
+		case !s.nodeExists:
+			outcome = outcomeNoAnnotation
+		case status.IsSuccess():
+			outcome = outcomeHonored
+		default:
+			outcome = outcomeStickyNodeMissing
+		}
+	}
+	prefilterOutcomes.WithLabelValues(outcome).Inc()
+	if outcome == outcomeStickyNodeMissing {
+		pl.pending.set(pod.UID, s)
+	} else {
+		pl.pending.set(pod.UID, nil)
+	}
+}
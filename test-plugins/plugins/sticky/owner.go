@@ -0,0 +1,90 @@
+package sticky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// isSupportedKind returns true when the owner kind is listed in StickyPodArgs.SupportedKinds.
+func (pl *StickyPod) isSupportedKind(kind string) bool {
+	for _, k := range pl.args.SupportedKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// getOwner gets the pod owner of the given kind, only its metadata is used.
+func (pl *StickyPod) getOwner(ctx context.Context, kind, ns, name string, opts metav1.GetOptions) (metav1.Object, error) {
+	client := pl.Handler.ClientSet()
+	switch kind {
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(ns).Get(ctx, name, opts)
+	case "ReplicaSet":
+		return client.AppsV1().ReplicaSets(ns).Get(ctx, name, opts)
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(ns).Get(ctx, name, opts)
+	case "Job":
+		return client.BatchV1().Jobs(ns).Get(ctx, name, opts)
+	case "ReplicationController":
+		return client.CoreV1().ReplicationControllers(ns).Get(ctx, name, opts)
+	default:
+		return nil, fmt.Errorf("unsupported owner kind %s", kind)
+	}
+}
+
+// patchOwnerAnnotations merges annotations into the metadata of the pod owner.
+func (pl *StickyPod) patchOwnerAnnotations(ctx context.Context, kind, ns, name string, annotations map[string]string) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+
+	client := pl.Handler.ClientSet()
+	opts := metav1.PatchOptions{}
+	switch kind {
+	case "StatefulSet":
+		_, err = client.AppsV1().StatefulSets(ns).Patch(ctx, name, types.MergePatchType, data, opts)
+	case "ReplicaSet":
+		_, err = client.AppsV1().ReplicaSets(ns).Patch(ctx, name, types.MergePatchType, data, opts)
+	case "DaemonSet":
+		_, err = client.AppsV1().DaemonSets(ns).Patch(ctx, name, types.MergePatchType, data, opts)
+	case "Job":
+		_, err = client.BatchV1().Jobs(ns).Patch(ctx, name, types.MergePatchType, data, opts)
+	case "ReplicationController":
+		_, err = client.CoreV1().ReplicationControllers(ns).Patch(ctx, name, types.MergePatchType, data, opts)
+	default:
+		err = fmt.Errorf("unsupported owner kind %s", kind)
+	}
+	return err
+}
+
+// recordValue appends value to the comma separated annotation key of the pod owner.
+// The owner is read again without the cache, other replicas may have recorded their nodes meanwhile.
+func (pl *StickyPod) recordValue(ctx context.Context, kind, ns, name, key, value string) error {
+	owner, err := pl.getOwner(ctx, kind, ns, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	if v := owner.GetAnnotations()[key]; v != "" {
+		values = strings.Split(v, ",")
+	}
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+	values = append(values, value)
+
+	return pl.patchOwnerAnnotations(ctx, kind, ns, name, map[string]string{key: strings.Join(values, ",")})
+}
@@ -0,0 +1,79 @@
+package sticky
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/helper"
+)
+
+// Score invoked at the score extension point.
+// Sticky nodes (or nodes in the sticky domains) get framework.MaxNodeScore, other nodes get 0.
+// When the owner sets sticky-ordered, nodes listed earlier in sticky-nodes score higher than the later ones.
+// How much stickiness weighs against other score plugins is the plugin weight
+// configured in the KubeSchedulerConfiguration profile.
+func (pl *StickyPod) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	r, err := getStickyState(state)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "Score: read preFilter scheduling context failed", "pod", klog.KObj(pod))
+		return 0, framework.NewStatus(framework.Error, err.Error())
+	}
+
+	if !r.nodeExists {
+		return 0, nil
+	}
+
+	if len(r.domains) != 0 {
+		nodeInfo, err := pl.Handler.SnapshotSharedLister().NodeInfos().Get(nodeName)
+		if err != nil {
+			return 0, framework.NewStatus(framework.Error, fmt.Sprintf("get node %s from snapshot: %v", nodeName, err))
+		}
+		if i, ok := domainIndex(nodeInfo.Node(), r.topologyKey, r.domains); ok {
+			return r.score(i, len(r.domains)), nil
+		}
+		return 0, nil
+	}
+
+	for i, v := range r.NodeNames {
+		if v == nodeName {
+			return r.score(i, len(r.NodeNames)), nil
+		}
+	}
+
+	return 0, nil
+}
+
+// score of the i-th of n sticky nodes or domains.
+func (s *stickyState) score(i, n int) int64 {
+	if !s.ordered {
+		return framework.MaxNodeScore
+	}
+	return framework.MaxNodeScore - int64(i)*framework.MaxNodeScore/int64(n)
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *StickyPod) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+// NormalizeScore invoked after scoring all nodes, scales the highest sticky node score to framework.MaxNodeScore.
+func (pl *StickyPod) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
+	return helper.DefaultNormalizeScore(framework.MaxNodeScore, false, scores)
+}
+
+// getStickyState reads the stickyState written by PreFilter from the cycle state.
+func getStickyState(state *framework.CycleState) (*stickyState, error) {
+	s, err := state.Read(stateKey)
+	if err != nil {
+		return nil, fmt.Errorf("read preFilter state fail: %v", err)
+	}
+
+	r, ok := s.(*stickyState)
+	if !ok {
+		return nil, fmt.Errorf("convert %+v to stickyState fail", s)
+	}
+	return r, nil
+}
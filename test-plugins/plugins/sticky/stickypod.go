@@ -4,12 +4,26 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	schedulermetrics "k8s.io/kubernetes/pkg/scheduler/metrics"
+
+	"test-plugins/apis/config/scheme"
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/apis/config/validation"
+	"test-plugins/generated/clientset/versioned"
+	stickylisters "test-plugins/generated/listers/sticky/v1alpha1"
 )
 
 const (
@@ -19,26 +33,67 @@ const (
 
 	kindNode = "Node"
 
-	// Annotation key on VirtualMachine, value is the sticky node (if not empty)
-	// Here we assume one VM has only one Pod.
-	stickyAnnotationKey = "sticky-nodes"
+	// Annotation key on the owner selecting how strict the stickiness is,
+	// value is required or preferred, defaults to StickyPodArgs.DefaultMode.
+	// The owner annotation listing the sticky nodes is StickyPodArgs.AnnotationKey.
+	ModeAnnotationKey = "sticky-mode"
+	// Annotation key on the owner, "true" means nodes listed earlier in
+	// sticky-nodes are preferred over the later ones.
+	OrderedAnnotationKey = "sticky-ordered"
 )
 
 var (
 	_ framework.PreFilterPlugin = &StickyPod{}
 	_ framework.FilterPlugin    = &StickyPod{}
+	_ framework.ScorePlugin     = &StickyPod{}
 	_ framework.PostBindPlugin  = &StickyPod{}
+
+	_ framework.EnqueueExtensions = &StickyPod{}
 )
 
 type StickyPod struct {
 	//ClientSet *kubernetes.Clientset
 	Handler framework.Handle
+	args    *configv1beta2.StickyPodArgs
+	// bindingClient 和 bindingLister 只在 UseStickyBindings 时初始化
+	bindingClient versioned.Interface
+	bindingLister stickylisters.StickyBindingLister
+	// pending 记录等待 sticky node 的 pod，用于 pendingStickyPods 指标
+	pending *pendingTracker
+	// recentEvents 记录最近发过的 event，避免大规模滚动时重复刷屏
+	recentEvents *cache.LRUExpireCache
+	// pvcLister 和 pvLister 只在 UseLocalVolumes 时初始化
+	pvcLister corelisters.PersistentVolumeClaimLister
+	pvLister  corelisters.PersistentVolumeLister
 }
 type stickyState struct {
 	nodeExists bool
 	// 多个node，逗号分隔
 	//nodeList  []*v1.Node
 	NodeNames []string
+	// preferred 为 true 时不过滤节点，只在 Score 阶段给 sticky node 打高分
+	preferred bool
+	// ordered 为 true 时 NodeNames 中越靠前的节点得分越高
+	ordered bool
+	// nodeSet 为 NodeNames 中可用的节点，作为 PreFilterResult 交给 scheduler
+	nodeSet sets.Set[string]
+	// topologyKey 不为空时按拓扑域 sticky，domains 为 pod 可以去的 node label 值
+	topologyKey string
+	domains     []string
+	// fallback 为 true 时 owner 有 sticky nodes 但都不可用，本次调度忽略 stickiness
+	fallback bool
+	// fallbackDomains 为 SameTopology 兜底时允许的 FallbackTopologyKey 取值
+	fallbackDomains sets.Set[string]
+	// volumeConflict 为 true 时 pod 的 local PV 不在 sticky nodes/domains 里，等节点恢复也没用
+	volumeConflict bool
+	// owner 记录 pod 的 controller，PostBind 回写 annotation 时使用
+	ownerKind string
+	ownerName string
+	ownerUID  types.UID
+	// owner 为从 API 读到的 owner，只在按 annotation sticky 时设置，用于在 owner 上发 event
+	owner runtime.Object
+	// binding 为 pod 使用的 StickyBinding 名字，PostBind 往里面记录调度历史
+	binding string
 }
 
 // Name returns name of the plugin
@@ -48,24 +103,112 @@ func (pl *StickyPod) Name() string {
 
 // NewPlugin New initializes a new plugin and returns it.
 // PluginFactory is a function that builds a plugin.
-// type PluginFactory = func(configuration runtime.Object, f framework.Handle) (framework.Plugin, error)
-func NewPlugin(_ runtime.Object, handler framework.Handle) (framework.Plugin, error) {
+// type PluginFactory = func(ctx context.Context, configuration runtime.Object, f framework.Handle) (framework.Plugin, error)
+func NewPlugin(ctx context.Context, obj runtime.Object, handler framework.Handle) (framework.Plugin, error) {
+	logger := klog.FromContext(ctx).WithValues("plugin", Name)
+	logger.Info("Initializing StickyPod scheduling plugin")
 
-	klog.Infof("Initializing StickyPod scheduling plugin")
+	args, err := DecodeArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("StickyPod args", "annotationKey", *args.AnnotationKey, "supportedKinds", args.SupportedKinds,
+		"defaultMode", *args.DefaultMode, "recordOnBind", *args.RecordOnBind, "missingNodesPolicy", *args.MissingNodesPolicy,
+		"waitTimeoutSeconds", *args.WaitTimeoutSeconds, "topologyKey", *args.TopologyKey, "fallbackTopologyKey", *args.FallbackTopologyKey,
+		"useStickyBindings", *args.UseStickyBindings, "maxReplicasPerNode", *args.MaxReplicasPerNode, "useLocalVolumes", *args.UseLocalVolumes)
 
 	pl := StickyPod{
 		Handler: handler,
+		args:    args,
+		pending: newPendingTracker(),
+
+		recentEvents: newEventCache(),
+	}
+	RegisterMetrics()
+	if _, err := handler.SharedInformerFactory().Core().V1().Pods().Informer().AddEventHandler(pl.pending.podDeleteHandler()); err != nil {
+		return nil, fmt.Errorf("add pod event handler: %w", err)
+	}
+	if *args.UseLocalVolumes {
+		// 在 scheduler 启动 informer 之前拿 lister，informer 会跟着一起启动
+		pl.pvcLister = handler.SharedInformerFactory().Core().V1().PersistentVolumeClaims().Lister()
+		pl.pvLister = handler.SharedInformerFactory().Core().V1().PersistentVolumes().Lister()
+	}
+	if *args.UseStickyBindings {
+		if err := pl.startBindingInformer(ctx, handler); err != nil {
+			return nil, err
+		}
 	}
 
 	return &pl, nil
 }
 
+// DecodeArgs decodes, defaults and validates the pluginConfig args of StickyPod like NewPlugin does,
+// so tools writing scheduler configs can check the args they write.
+func DecodeArgs(obj runtime.Object) (*configv1beta2.StickyPodArgs, error) {
+	args, err := getArgs(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateStickyPodArgs(field.NewPath("args"), args); err != nil {
+		return nil, fmt.Errorf("invalid %s args: %w", Name, err)
+	}
+	return args, nil
+}
+
+// getArgs decodes the pluginConfig args of StickyPod and applies the defaults.
+// The scheduler doesn't know StickyPodArgs, so they arrive as runtime.Unknown.
+func getArgs(obj runtime.Object) (*configv1beta2.StickyPodArgs, error) {
+	args := &configv1beta2.StickyPodArgs{}
+	switch t := obj.(type) {
+	case nil:
+	case *configv1beta2.StickyPodArgs:
+		args = t.DeepCopy()
+	case *runtime.Unknown:
+		if len(t.Raw) != 0 {
+			if err := runtime.DecodeInto(scheme.Codecs.UniversalDecoder(), t.Raw, args); err != nil {
+				return nil, fmt.Errorf("decode %s args: %w", Name, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("want args of type StickyPodArgs, got %T", obj)
+	}
+	scheme.Scheme.Default(args)
+	return args, nil
+}
+
 // PreFilter invoked at the preFilter extension point.
-func (pl *StickyPod) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) *framework.Status {
-	klog.Infof("Prefilter unscheduled pod: %s/%s", pod.Namespace, pod.Name)
-	s := stickyState{false, nil}
+// With required node stickiness the available sticky nodes are returned as PreFilterResult,
+// so the scheduler only runs Filter on them. Pods without stickiness skip Filter.
+func (pl *StickyPod) PreFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	status := pl.preFilter(ctx, state, pod)
+	if !status.IsSuccess() {
+		return nil, status
+	}
+	s, err := getStickyState(state)
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+	if !s.nodeExists && s.fallbackDomains.Len() == 0 {
+		return nil, framework.NewStatus(framework.Skip)
+	}
+	if names := s.preFilterNodeNames(); names != nil {
+		return &framework.PreFilterResult{NodeNames: names}, status
+	}
+	return nil, status
+}
+
+// preFilter looks the stickiness of the pod up and writes it to the cycle state.
+func (pl *StickyPod) preFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod) (status *framework.Status) {
+	logger := klog.FromContext(ctx)
+	logger.Info("Prefilter unscheduled pod", "pod", klog.KObj(pod))
+	s := stickyState{}
+	// outcome 只在 status 和 s 看不出结果时提前设置
+	outcome := ""
+	start := time.Now()
 	defer func() {
 		state.Write(stateKey, &s)
+		prefilterDuration.Observe(schedulermetrics.SinceInSeconds(start))
+		pl.recordPreFilter(pod, &s, status, outcome)
 	}()
 
 	// Get pod owner reference
@@ -73,165 +216,217 @@ func (pl *StickyPod) PreFilter(ctx context.Context, state *framework.CycleState,
 	// 或者这里就应该没有逻辑的，因为前端页面可以控制，不开启sticky node的pod不使用这个scheduler
 	podOwnerRef := getPodOwnerRef(pod)
 	if podOwnerRef == nil {
-		klog.Infof("PreFilter: pod OwnerRef not found or doesn't meet expectation, skip sticky operations")
+		logger.Info("PreFilter: pod OwnerRef not found or doesn't meet expectation, skip sticky operations", "pod", klog.KObj(pod))
 		//s.nodeList, s.NodeNames = localCommon.NodeCacheInfo.List()
 		return framework.NewStatus(framework.Success, "Pod owner ref not found, return")
 	}
+	if !pl.isSupportedKind(podOwnerRef.Kind) {
+		logger.Info("PreFilter: pod OwnerRef kind not supported, skip sticky operations", "pod", klog.KObj(pod), "kind", podOwnerRef.Kind)
+		return framework.NewStatus(framework.Success, "Pod owner ref kind not found, return")
+	}
 
 	// Get sticky info
 	ownerName := podOwnerRef.Name
 	ns := pod.Namespace
-	klog.Infof("PreFilter: parent is %s %s in %s namespace", podOwnerRef.Kind, ownerName, ns)
+	logger.Info("PreFilter: found parent", "pod", klog.KObj(pod), "kind", podOwnerRef.Kind, "owner", klog.KRef(ns, ownerName))
+	s.ownerKind, s.ownerName, s.ownerUID = podOwnerRef.Kind, ownerName, podOwnerRef.UID
 
-	// 这是不是能用反射合并下逻辑
-	switch podOwnerRef.Kind {
-	case "StatefulSet":
-		statefulSet, err := pl.Handler.ClientSet().AppsV1().StatefulSets(ns).Get(context.TODO(), ownerName, metav1.GetOptions{ResourceVersion: "0"})
+	// StickyBinding 优先，没有 binding 时再看 owner 上的 annotation
+	if pl.bindingLister != nil {
+		binding, err := pl.bindingFor(pod, podOwnerRef.Kind, ownerName)
 		if err != nil {
-			klog.Infof("Get %s %s/%s failed: %v", podOwnerRef.Kind, ns, ownerName, err)
-			return framework.NewStatus(framework.Error, "get vmi failed")
+			logger.Error(err, "PreFilter: list StickyBindings failed", "namespace", ns)
+			return framework.NewStatus(framework.Error, "list StickyBindings failed")
 		}
-		if _, ok := statefulSet.Annotations[stickyAnnotationKey]; !ok {
-			return framework.NewStatus(framework.Success, "Pod don't stick nodes ")
+		if binding != nil {
+			logger.Info("PreFilter: pod uses StickyBinding", "pod", klog.KObj(pod), "stickyBinding", binding.Name)
+			if !pl.stateFromBinding(binding, &s) {
+				return pl.stickToLocalVolumes(ctx, pod, &s)
+			}
+			return pl.checkStickiness(ctx, pod, &s)
 		}
-		s.nodeExists = true
-
-		stickyNodeList := strings.Split(statefulSet.Annotations[stickyAnnotationKey], ",")
-		s.NodeNames = stickyNodeList
-
-		//s.nodeList = make([]*v1.Node, 0, len(stickyNodeList))
-		//s.NodeNames = make([]string, 0, len(stickyNodeList))
-		//for _, v := range stickyNodeList {
-		//	node, exist := localCommon.NodeCacheInfo.GetNode(v)
-		//	if !exist {
-		//		continue
-		//	}
-		//	s.nodeList = append(s.nodeList, node)
-		//	s.NodeNames = append(s.NodeNames, v)
-		//}
-
-		klog.Infof("PreFilter: pod  has sticky nodes %s ,write to scheduling context", s.NodeNames)
-		return framework.NewStatus(framework.Success, "Check pod finish, return")
-	case "ReplicaSet":
-		replicaSet, err := pl.Handler.ClientSet().AppsV1().ReplicaSets(ns).Get(context.TODO(), ownerName, metav1.GetOptions{ResourceVersion: "0"})
-		if err != nil {
-			klog.Infof("Get %s %s/%s failed: %v", podOwnerRef.Kind, ns, ownerName, err)
-			return framework.NewStatus(framework.Error, "get vmi failed")
+	}
+
+	owner, err := pl.getOwner(ctx, podOwnerRef.Kind, ns, ownerName, metav1.GetOptions{ResourceVersion: "0"})
+	if err != nil {
+		logger.Error(err, "Get owner failed", "kind", podOwnerRef.Kind, "owner", klog.KRef(ns, ownerName))
+		if apierrors.IsNotFound(err) {
+			outcome = outcomeOwnerNotFound
+		}
+		return framework.NewStatus(framework.Error, fmt.Sprintf("get %s failed", podOwnerRef.Kind))
+	}
+	annotations := owner.GetAnnotations()
+	if obj, ok := owner.(runtime.Object); ok {
+		s.owner = obj
+		pl.checkOwnerAnnotations(ctx, obj, annotations)
+	}
+	s.topologyKey = pl.topologyKeyFor(annotations)
+	if v, ok := annotations[DomainsAnnotationKey]; ok && s.topologyKey != "" {
+		// 按拓扑域 sticky，sticky-domains 中是 topologyKey 对应的 node label 值
+		s.domains = strings.Split(v, ",")
+	} else if v, ok := annotations[*pl.args.AnnotationKey]; ok {
+		s.NodeNames = strings.Split(v, ",")
+	} else {
+		return pl.stickToLocalVolumes(ctx, pod, &s)
+	}
+	s.nodeExists = true
+	pl.parseStickyAnnotations(annotations, &s)
+
+	return pl.checkStickiness(ctx, pod, &s)
+}
+
+// checkStickiness merges the sticky nodes or domains in s with the local PVs of the pod
+// and checks they are available.
+func (pl *StickyPod) checkStickiness(ctx context.Context, pod *v1.Pod, s *stickyState) *framework.Status {
+	if status := pl.mergeLocalVolumeNodes(ctx, pod, s); status != nil {
+		return status
+	}
+	return pl.checkAvailability(ctx, pod, s)
+}
+
+// checkAvailability validates the sticky nodes or domains in s against the scheduler snapshot,
+// and applies MissingNodesPolicy when none of them is available.
+func (pl *StickyPod) checkAvailability(ctx context.Context, pod *v1.Pod, s *stickyState) *framework.Status {
+	logger := klog.FromContext(ctx)
+	if len(s.domains) != 0 {
+		logger.Info("PreFilter: pod has sticky domains, write to scheduling context", "pod", klog.KObj(pod), "topologyKey", s.topologyKey, "domains", s.domains)
+		if s.preferred {
+			return framework.NewStatus(framework.Success, "Check pod finish, return")
 		}
-		if _, ok := replicaSet.Annotations[stickyAnnotationKey]; !ok {
-			return framework.NewStatus(framework.Success, "Pod don't stick nodes ")
+		if reasons, ok := pl.checkStickyDomains(s.topologyKey, s.domains); !ok {
+			msg := fmt.Sprintf("sticky %s %s are not available: %s", s.topologyKey, strings.Join(s.domains, ","), strings.Join(reasons, ", "))
+			// 拓扑域本身已经是兜底的范围，SameTopology 没有更大的范围可以退
+			return pl.applyMissingNodesPolicy(ctx, pod, s, msg, nil)
 		}
-		s.nodeExists = true
-
-		stickyNodeList := strings.Split(replicaSet.Annotations[stickyAnnotationKey], ",")
-		s.NodeNames = stickyNodeList
-
-		//s.nodeList = make([]*v1.Node, 0, len(stickyNodeList))
-		//s.NodeNames = make([]string, 0, len(stickyNodeList))
-		//
-		//for _, v := range stickyNodeList {
-		//	node, exist := localCommon.NodeCacheInfo.GetNode(v)
-		//	if !exist {
-		//		continue
-		//	}
-		//	s.nodeList = append(s.nodeList, node)
-		//	s.NodeNames = append(s.NodeNames, v)
-		//}
-		klog.Infof("PreFilter: pod  has sticky nodes %s ,write to scheduling context", s.NodeNames)
 		return framework.NewStatus(framework.Success, "Check pod finish, return")
+	}
 
-	default:
-		klog.Infof("PreFilter: pod OwnerRef kind not found, skip sticky operations")
-		return framework.NewStatus(framework.Success, "Pod owner ref kind not found, return")
+	logger.Info("PreFilter: pod has sticky nodes, write to scheduling context", "pod", klog.KObj(pod), "nodes", s.NodeNames)
+	if s.preferred {
+		return framework.NewStatus(framework.Success, "Check pod finish, return")
+	}
 
+	// 校验 sticky node 是否还在 scheduler snapshot 中且可调度，都不可用时按 MissingNodesPolicy 处理
+	available, known, reasons := pl.checkStickyNodes(s.NodeNames)
+	s.nodeSet = sets.New(available...)
+	pl.warnNodesNotFound(ctx, s, known)
+	if len(available) == 0 {
+		msg := fmt.Sprintf("sticky nodes %s are not available: %s", strings.Join(s.NodeNames, ","), strings.Join(reasons, ", "))
+		return pl.applyMissingNodesPolicy(ctx, pod, s, msg, known)
+	}
+	if len(reasons) != 0 {
+		logger.Info("PreFilter: some sticky nodes are not available", "pod", klog.KObj(pod), "reasons", reasons)
 	}
 
+	return framework.NewStatus(framework.Success, "Check pod finish, return")
 }
 
 func (pl *StickyPod) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
-	klog.Infof("Filter %s/%s: start", pod.Namespace, pod.Name)
-	klog.Infof(":::::::::::::::::::::::%s\n", nodeInfo.Node().Name)
+	// Filter 对每个节点都会调用一次，日志只在高 verbosity 下输出
+	logger := klog.FromContext(ctx)
+	logger.V(5).Info("Filter: start", "pod", klog.KObj(pod), "node", klog.KObj(nodeInfo.Node()))
+	start := time.Now()
+	defer func() {
+		filterDuration.Observe(schedulermetrics.SinceInSeconds(start))
+	}()
 
 	s, err := state.Read(stateKey)
 	if err != nil {
-		klog.Infof("Filter: pod %s/%s: read preFilter scheduling context failed: %v", pod.Namespace, pod.Name, err)
+		logger.Error(err, "Filter: read preFilter scheduling context failed", "pod", klog.KObj(pod))
 		return framework.NewStatus(framework.Error, fmt.Sprintf("read preFilter state fail: %v", err))
 	}
 
 	// 流弊...
 	r, ok := s.(*stickyState)
 	if !ok {
-		klog.Errorf("Filter: pod %s/%s: scheduling context found but sticky node missing", pod.Namespace, pod.Name)
+		logger.Error(nil, "Filter: scheduling context found but sticky node missing", "pod", klog.KObj(pod))
 		return framework.NewStatus(framework.Error, fmt.Sprintf("convert %+v to stickyState fail", s))
 	}
 
+	if r.fallbackDomains.Len() != 0 {
+		return pl.filterFallbackDomain(ctx, nodeInfo, r)
+	}
 	if !r.nodeExists {
-		klog.Infof("Filter: pod %v/%v, sticky node not exist, return success", pod.Namespace, pod.Name)
+		logger.V(5).Info("Filter: sticky node not exist, return success", "pod", klog.KObj(pod))
 		return nil
 	}
-	flag := false
-	for _, v := range r.NodeNames {
-		if nodeInfo.Node().Name == v {
-			flag = true
+	if status := pl.filterExclusive(ctx, pod, r, nodeInfo); status != nil {
+		return status
+	}
+	if r.preferred {
+		logger.V(5).Info("Filter: sticky nodes are preferred only, leave them to Score", "pod", klog.KObj(pod))
+		return nil
+	}
+	if len(r.domains) != 0 {
+		if _, ok := domainIndex(nodeInfo.Node(), r.topologyKey, r.domains); !ok {
+			m := fmt.Sprintf("%s node not in sticky %s %v", nodeInfo.Node().Name, r.topologyKey, r.domains)
+			logger.V(5).Info(m)
+			return framework.NewStatus(framework.Unschedulable, m)
 		}
+		return nil
 	}
-	if !flag {
-		m := fmt.Sprintf("%s node not in sticky nodes list %v\n", nodeInfo.Node().Name, r.NodeNames)
-		klog.Infof(m)
+	if names := r.preFilterNodeNames(); names != nil && !names.Has(nodeInfo.Node().Name) {
+		m := fmt.Sprintf("%s node not in sticky nodes list %v", nodeInfo.Node().Name, r.NodeNames)
+		logger.V(5).Info(m)
 		return framework.NewStatus(framework.Unschedulable, m)
 	}
 
-	// 这是不是直接用nodeInfo *framework.NodeInfo就行
-	//nodes, err := pl.Handler.SnapshotSharedLister().NodeInfos().List()
-	//if err != nil {
-	//	klog.Infof("Filter: found node info error: %v", err)
-	//	return nil
-	//}
-	//nodeMap := make(map[string]bool)
-	//for _, v := range nodes {
-	//	nodeMap[v.Node().Name] = true
-	//}
-	//nodeCnt := 0
-	//availableNode := make([]string, 0, len(r.NodeNames))
-	//for _, node := range r.NodeNames {
-	//	if _, ok := nodeMap[node]; ok {
-	//		nodeCnt++
-	//	}
-	//}
-	//if nodeCnt == 0 {
-	//	return framework.NewStatus(framework.Unschedulable, "sticky node are not available")
-	//}
-	//
-	//klog.Infof("Filter %s/%s: given node is the sticky node %s, use it", pod.Namespace, pod.Name, strings.Join(availableNode, ","))
-	klog.Infof("Filter %s/%s: finish", pod.Namespace, pod.Name)
+	logger.V(5).Info("Filter: finish", "pod", klog.KObj(pod))
 	return nil
 }
 
 func (pl *StickyPod) PostBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
-	klog.Infof("PostBind %s/%s: start", pod.Namespace, pod.Name)
+	logger := klog.FromContext(ctx)
+	logger.Info("PostBind: start", "pod", klog.KObj(pod), "node", nodeName)
 
 	s, err := state.Read(stateKey)
 	if err != nil {
-		klog.Infof("PostBind: pod %s/%s: read preFilter scheduling context failed: %v", pod.Namespace, pod.Name, err)
+		logger.Error(err, "PostBind: read preFilter scheduling context failed", "pod", klog.KObj(pod))
 		return
 	}
 
 	r, ok := s.(*stickyState)
 	if !ok {
-		klog.Errorf("PostBind: pod %s/%s: convert failed", pod.Namespace, pod.Name)
+		logger.Error(nil, "PostBind: convert failed", "pod", klog.KObj(pod))
 		return
 	}
-
-	if r.nodeExists {
-		klog.Errorf("PostBind: Pod already has sticky annotation, return")
+	if pl.bindingClient != nil && r.ownerKind != "" {
+		pl.pinnedEvent(ctx, pod, r, nodeName)
+		if err := pl.recordBinding(ctx, pod, r, nodeName); err != nil {
+			logger.Error(err, "PostBind: record StickyBinding failed", "pod", klog.KObj(pod))
+		}
+		logger.Info("PostBind: finish", "pod", klog.KObj(pod))
 		return
 	}
 
-	// 可尝试，不指定sticky node，当pod第一次调度后填充当前节点到annotation，用于下次sticky
+	pl.pinnedEvent(ctx, pod, r, nodeName)
+	if r.nodeExists || r.fallback {
+		logger.Info("PostBind: pod already has sticky annotation, return", "pod", klog.KObj(pod))
+		return
+	}
+	if !*pl.args.RecordOnBind || r.ownerKind == "" {
+		logger.Info("PostBind: recordOnBind disabled or pod has no supported owner, return", "pod", klog.KObj(pod))
+		return
+	}
 
-	klog.Infof("PostBind: annotating selected node %s to pod", nodeName)
+	// 不指定sticky node时，pod第一次调度后填充当前节点（或其拓扑域）到owner annotation，用于下次sticky
+	key, value := *pl.args.AnnotationKey, nodeName
+	if r.topologyKey != "" {
+		domain, err := pl.nodeDomain(nodeName, r.topologyKey)
+		if err != nil {
+			logger.Error(err, "PostBind: get node domain failed", "pod", klog.KObj(pod))
+			return
+		}
+		key, value = DomainsAnnotationKey, domain
+	}
+	logger.Info("PostBind: annotating owner", "key", key, "value", value, "kind", r.ownerKind, "owner", klog.KRef(pod.Namespace, r.ownerName))
+	if err := pl.recordValue(ctx, r.ownerKind, pod.Namespace, r.ownerName, key, value); err != nil {
+		logger.Error(err, "PostBind: annotate owner failed", "kind", r.ownerKind, "owner", klog.KRef(pod.Namespace, r.ownerName))
+		return
+	}
+	pl.event(ctx, pod, v1.EventTypeNormal, reasonRecorded, "Binding", fmt.Sprintf("recorded %s %s as sticky on %s %s", key, value, r.ownerKind, r.ownerName))
 
-	klog.Infof("PostBind %s/%s: finish", pod.Namespace, pod.Name)
+	logger.Info("PostBind: finish", "pod", klog.KObj(pod))
 }
 
 // PreFilterExtensions returns prefilter extensions, pod add and remove.
@@ -251,6 +446,16 @@ func (pl *StickyPod) RemovePod(ctx context.Context, cycleState *framework.CycleS
 	return framework.NewStatus(framework.Success, "")
 }
 
+// parseStickyAnnotations fills s with the sticky mode found in the owner annotations.
+func (pl *StickyPod) parseStickyAnnotations(annotations map[string]string, s *stickyState) {
+	mode := *pl.args.DefaultMode
+	if v, ok := annotations[ModeAnnotationKey]; ok {
+		mode = configv1beta2.StickyMode(v)
+	}
+	s.preferred = mode == configv1beta2.StickyModePreferred
+	s.ordered = annotations[OrderedAnnotationKey] == "true"
+}
+
 // getPodOwnerRef returns the controller of the pod
 func getPodOwnerRef(pod *v1.Pod) *metav1.OwnerReference {
 	if len(pod.OwnerReferences) == 0 {
@@ -258,10 +463,11 @@ func getPodOwnerRef(pod *v1.Pod) *metav1.OwnerReference {
 	}
 
 	// list？难道一下会有很多unscheduled pods
-	klog.Info("num::::::::::::::", len(pod.OwnerReferences))
+	klog.V(5).InfoS("Pod owner references", "pod", klog.KObj(pod), "count", len(pod.OwnerReferences))
 	for i := range pod.OwnerReferences {
 		ref := &pod.OwnerReferences[i]
-		if *ref.Controller && ref.Kind != kindNode {
+		// Controller 是可选字段，手写的 ownerReference 可能没有设置
+		if ref.Controller != nil && *ref.Controller && ref.Kind != kindNode {
 			return ref
 		}
 	}
@@ -269,6 +475,16 @@ func getPodOwnerRef(pod *v1.Pod) *metav1.OwnerReference {
 	return nil
 }
 
+// preFilterNodeNames returns the only nodes the pod can pass Filter on, nil means every node.
+// PreFilter hands this set to the scheduler as PreFilterResult, so in large clusters Filter
+// only runs on the sticky nodes instead of rejecting all the others.
+func (s *stickyState) preFilterNodeNames() sets.Set[string] {
+	if !s.nodeExists || s.preferred || len(s.domains) != 0 {
+		return nil
+	}
+	return s.nodeSet
+}
+
 // Clone don't really copy the data since there is no need
 func (s *stickyState) Clone() framework.StateData {
 	return s
@@ -0,0 +1,571 @@
+package sticky
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	metricstestutil "k8s.io/component-base/metrics/testutil"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/ptr"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/apis/sticky/v1alpha1"
+	stickyfake "test-plugins/generated/clientset/versioned/fake"
+	"test-plugins/test/testutil"
+)
+
+func TestGetPodOwnerRef(t *testing.T) {
+	tests := []struct {
+		name string
+		refs []metav1.OwnerReference
+		want string
+	}{
+		{
+			name: "no owner references",
+		},
+		{
+			name: "controller",
+			refs: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "web", Controller: ptr.To(true)}},
+			want: "web",
+		},
+		{
+			name: "controller not set",
+			refs: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "web"}},
+		},
+		{
+			name: "not the controller",
+			refs: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "web", Controller: ptr.To(false)}},
+		},
+		{
+			name: "node owner of a static pod",
+			refs: []metav1.OwnerReference{{Kind: kindNode, Name: "n1", Controller: ptr.To(true)}},
+		},
+		{
+			name: "controller after other owners",
+			refs: []metav1.OwnerReference{
+				{Kind: "ConfigMap", Name: "cm"},
+				{Kind: "ReplicaSet", Name: "web-abc", Controller: ptr.To(true)},
+			},
+			want: "web-abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", OwnerReferences: tt.refs}}
+			ref := getPodOwnerRef(pod)
+			got := ""
+			if ref != nil {
+				got = ref.Name
+			}
+			if got != tt.want {
+				t.Errorf("getPodOwnerRef() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// defaultNodes are Ready nodes, n1 and n2 in zone z1, n3 in zone z2.
+func defaultNodes() []*v1.Node {
+	return []*v1.Node{testutil.MakeNode("n1", "z1"), testutil.MakeNode("n2", "z1"), testutil.MakeNode("n3", "z2")}
+}
+
+func TestPreFilterAndFilter(t *testing.T) {
+	type testCase struct {
+		name  string
+		args  *configv1beta2.StickyPodArgs
+		nodes []*v1.Node
+		owner runtime.Object
+		pod   *v1.Pod
+		// wantCode is the PreFilter status code.
+		wantCode framework.Code
+		// wantResult is the PreFilterResult, nil means all nodes.
+		wantResult []string
+		// wantFeasible are the nodes passing Filter, checked when PreFilter succeeded.
+		wantFeasible []string
+	}
+
+	var tests []testCase
+	for _, kind := range testutil.AllKinds {
+		tests = append(tests, testCase{
+			name:         kind + " with sticky nodes",
+			args:         testArgs(),
+			owner:        testutil.MakeOwner(kind, "web", map[string]string{"sticky-nodes": "n2"}),
+			pod:          testutil.MakePod("web-0", kind, "web"),
+			wantCode:     framework.Success,
+			wantResult:   []string{"n2"},
+			wantFeasible: []string{"n2"},
+		})
+	}
+	tests = append(tests, []testCase{
+		{
+			name:     "pod without controller",
+			args:     testArgs(),
+			pod:      testutil.MakePod("bare", "", ""),
+			wantCode: framework.Skip,
+		},
+		{
+			name: "owner kind not supported",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.SupportedKinds = []string{"StatefulSet"}
+			}),
+			owner:    testutil.MakeOwner("Job", "batch", map[string]string{"sticky-nodes": "n2"}),
+			pod:      testutil.MakePod("batch-0", "Job", "batch"),
+			wantCode: framework.Skip,
+		},
+		{
+			name:     "owner not found",
+			args:     testArgs(),
+			pod:      testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode: framework.Error,
+		},
+		{
+			name:     "owner without sticky annotation",
+			args:     testArgs(),
+			owner:    testutil.MakeOwner("StatefulSet", "web", nil),
+			pod:      testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode: framework.Skip,
+		},
+		{
+			name:         "preferred mode doesn't filter",
+			args:         testArgs(),
+			owner:        testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n2", ModeAnnotationKey: "preferred"}),
+			pod:          testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode:     framework.Success,
+			wantFeasible: []string{"n1", "n2", "n3"},
+		},
+		{
+			name:         "unavailable sticky nodes are left out",
+			args:         testArgs(),
+			nodes:        []*v1.Node{testutil.Cordoned(testutil.MakeNode("n1", "z1")), testutil.MakeNode("n2", "z1"), testutil.MakeNode("n3", "z2")},
+			owner:        testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1,n2,gone"}),
+			pod:          testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode:     framework.Success,
+			wantResult:   []string{"n2"},
+			wantFeasible: []string{"n2"},
+		},
+		{
+			name:     "Wait keeps the pod pending",
+			args:     testArgs(),
+			nodes:    []*v1.Node{testutil.Cordoned(testutil.MakeNode("n1", "z1")), testutil.NotReady(testutil.MakeNode("n2", "z1")), testutil.MakeNode("n3", "z2")},
+			owner:    testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1,n2,gone"}),
+			pod:      testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name: "Wait falls back after the timeout",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.WaitTimeoutSeconds = ptr.To[int64](60)
+			}),
+			owner:    testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "gone"}),
+			pod:      testutil.CreatedAgo(testutil.MakePod("web-0", "StatefulSet", "web"), 2*time.Minute),
+			wantCode: framework.Skip,
+		},
+		{
+			name: "Wait before the timeout",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.WaitTimeoutSeconds = ptr.To[int64](600)
+			}),
+			owner:    testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "gone"}),
+			pod:      testutil.CreatedAgo(testutil.MakePod("web-0", "StatefulSet", "web"), 2*time.Minute),
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name: "AnyNode falls back to any node",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicyAnyNode)
+			}),
+			owner:    testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "gone"}),
+			pod:      testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode: framework.Skip,
+		},
+		{
+			name: "SameTopology falls back to the zone of the sticky nodes",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicySameTopology)
+				args.FallbackTopologyKey = ptr.To(testutil.ZoneKey)
+			}),
+			nodes:        []*v1.Node{testutil.MakeNode("n1", "z1"), testutil.MakeNode("n2", "z1"), testutil.MakeNode("n3", "z2"), testutil.NotReady(testutil.MakeNode("n4", "z2"))},
+			owner:        testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n4"}),
+			pod:          testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode:     framework.Success,
+			wantFeasible: []string{"n3", "n4"},
+		},
+		{
+			name: "SameTopology without known sticky nodes",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicySameTopology)
+				args.FallbackTopologyKey = ptr.To(testutil.ZoneKey)
+			}),
+			owner:    testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "gone"}),
+			pod:      testutil.MakePod("web-0", "StatefulSet", "web"),
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+		{
+			name:         "sticky domains",
+			args:         testArgs(),
+			owner:        testutil.MakeOwner("ReplicaSet", "web", map[string]string{TopologyKeyAnnotationKey: testutil.ZoneKey, DomainsAnnotationKey: "z2"}),
+			pod:          testutil.MakePod("web-abc", "ReplicaSet", "web"),
+			wantCode:     framework.Success,
+			wantFeasible: []string{"n3"},
+		},
+		{
+			name: "sticky domains with the default topology key",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.TopologyKey = ptr.To(testutil.ZoneKey)
+			}),
+			owner:        testutil.MakeOwner("ReplicaSet", "web", map[string]string{DomainsAnnotationKey: "z1"}),
+			pod:          testutil.MakePod("web-abc", "ReplicaSet", "web"),
+			wantCode:     framework.Success,
+			wantFeasible: []string{"n1", "n2"},
+		},
+		{
+			name:     "sticky domains without available nodes",
+			args:     testArgs(),
+			owner:    testutil.MakeOwner("ReplicaSet", "web", map[string]string{TopologyKeyAnnotationKey: testutil.ZoneKey, DomainsAnnotationKey: "z9"}),
+			pod:      testutil.MakePod("web-abc", "ReplicaSet", "web"),
+			wantCode: framework.UnschedulableAndUnresolvable,
+		},
+	}...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			nodes := tt.nodes
+			if nodes == nil {
+				nodes = defaultNodes()
+			}
+			var objs []runtime.Object
+			if tt.owner != nil {
+				objs = append(objs, tt.owner)
+			}
+			pl := newTestPlugin(ctx, t, tt.args, nodes, nil, objs...)
+
+			state := framework.NewCycleState()
+			result, status := pl.PreFilter(ctx, state, tt.pod)
+			if status.Code() != tt.wantCode {
+				t.Fatalf("PreFilter status = %v, want %v", status, tt.wantCode)
+			}
+			var gotResult []string
+			if result != nil {
+				gotResult = sets.List(result.NodeNames)
+			}
+			if diff := cmp.Diff(tt.wantResult, gotResult); diff != "" {
+				t.Errorf("PreFilterResult (-want +got):\n%s", diff)
+			}
+			if tt.wantCode != framework.Success {
+				return
+			}
+
+			nodeInfos, err := pl.snapshot.NodeInfos().List()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var feasible []string
+			for _, nodeInfo := range nodeInfos {
+				if result != nil && !result.NodeNames.Has(nodeInfo.Node().Name) {
+					continue
+				}
+				if status := pl.Filter(ctx, state, tt.pod, nodeInfo); status.IsSuccess() {
+					feasible = append(feasible, nodeInfo.Node().Name)
+				}
+			}
+			// snapshot 按 zone 轮流列出节点，顺序不固定
+			sort.Strings(feasible)
+			if diff := cmp.Diff(tt.wantFeasible, feasible); diff != "" {
+				t.Errorf("feasible nodes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]int64
+	}{
+		{
+			name:        "sticky nodes",
+			annotations: map[string]string{"sticky-nodes": "n2,n1", ModeAnnotationKey: "preferred"},
+			want:        map[string]int64{"n1": 100, "n2": 100, "n3": 0},
+		},
+		{
+			name:        "ordered sticky nodes",
+			annotations: map[string]string{"sticky-nodes": "n2,n1", ModeAnnotationKey: "preferred", OrderedAnnotationKey: "true"},
+			want:        map[string]int64{"n1": 50, "n2": 100, "n3": 0},
+		},
+		{
+			name:        "sticky domains",
+			annotations: map[string]string{TopologyKeyAnnotationKey: testutil.ZoneKey, DomainsAnnotationKey: "z2", ModeAnnotationKey: "preferred"},
+			want:        map[string]int64{"n1": 0, "n2": 0, "n3": 100},
+		},
+		{
+			name: "no stickiness",
+			want: map[string]int64{"n1": 0, "n2": 0, "n3": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			pl := newTestPlugin(ctx, t, testArgs(), defaultNodes(), nil, testutil.MakeOwner("StatefulSet", "web", tt.annotations))
+			pod := testutil.MakePod("web-0", "StatefulSet", "web")
+
+			state := framework.NewCycleState()
+			if _, status := pl.PreFilter(ctx, state, pod); !status.IsSuccess() && !status.IsSkip() {
+				t.Fatalf("PreFilter: %v", status)
+			}
+			got := make(map[string]int64)
+			for name := range tt.want {
+				score, status := pl.Score(ctx, state, pod, name)
+				if !status.IsSuccess() {
+					t.Fatalf("Score %s: %v", name, status)
+				}
+				got[name] = score
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("scores (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPostBind(t *testing.T) {
+	type testCase struct {
+		name        string
+		args        *configv1beta2.StickyPodArgs
+		kind        string
+		annotations map[string]string
+		nodeName    string
+		// wantAnnotations are the owner annotations after PostBind.
+		wantAnnotations map[string]string
+		// wantEvent is the prefix of the event expected on the pod, empty for none.
+		wantEvent string
+	}
+	recordOnBind := func(args *configv1beta2.StickyPodArgs) { args.RecordOnBind = ptr.To(true) }
+
+	var tests []testCase
+	for _, kind := range testutil.AllKinds {
+		tests = append(tests, testCase{
+			name:            kind + " records the node",
+			args:            testArgs(recordOnBind),
+			kind:            kind,
+			nodeName:        "n2",
+			wantAnnotations: map[string]string{"sticky-nodes": "n2"},
+			wantEvent:       "Normal " + reasonRecorded,
+		})
+	}
+	tests = append(tests, []testCase{
+		{
+			name: "records the domain with a topology key",
+			args: testArgs(recordOnBind, func(args *configv1beta2.StickyPodArgs) {
+				args.TopologyKey = ptr.To(testutil.ZoneKey)
+			}),
+			kind:            "StatefulSet",
+			nodeName:        "n3",
+			wantAnnotations: map[string]string{DomainsAnnotationKey: "z2"},
+			wantEvent:       "Normal " + reasonRecorded,
+		},
+		{
+			name:     "RecordOnBind disabled",
+			args:     testArgs(),
+			kind:     "StatefulSet",
+			nodeName: "n2",
+		},
+		{
+			name:            "already sticky",
+			args:            testArgs(recordOnBind),
+			kind:            "StatefulSet",
+			annotations:     map[string]string{"sticky-nodes": "n1"},
+			nodeName:        "n1",
+			wantAnnotations: map[string]string{"sticky-nodes": "n1"},
+			wantEvent:       "Normal " + reasonPinned,
+		},
+		{
+			name: "fallback is not recorded",
+			args: testArgs(recordOnBind, func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicyAnyNode)
+			}),
+			kind:            "StatefulSet",
+			annotations:     map[string]string{"sticky-nodes": "gone"},
+			nodeName:        "n3",
+			wantAnnotations: map[string]string{"sticky-nodes": "gone"},
+			wantEvent:       "Warning " + reasonFallback,
+		},
+	}...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			pl := newTestPlugin(ctx, t, tt.args, defaultNodes(), nil, testutil.MakeOwner(tt.kind, "web", tt.annotations))
+			pod := testutil.MakePod("web-0", tt.kind, "web")
+
+			state := framework.NewCycleState()
+			if _, status := pl.PreFilter(ctx, state, pod); !status.IsSuccess() && !status.IsSkip() {
+				t.Fatalf("PreFilter: %v", status)
+			}
+			pl.PostBind(ctx, state, pod, tt.nodeName)
+
+			owner, err := pl.getOwner(ctx, tt.kind, testutil.Namespace, "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantAnnotations, owner.GetAnnotations()); diff != "" {
+				t.Errorf("owner annotations (-want +got):\n%s", diff)
+			}
+
+			events := pl.recordedEvents()
+			if tt.wantEvent == "" {
+				return
+			}
+			for _, e := range events {
+				if strings.HasPrefix(e, tt.wantEvent) {
+					return
+				}
+			}
+			t.Errorf("want event %q, got %v", tt.wantEvent, events)
+		})
+	}
+}
+
+func TestRecordBindingDomain(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodeName    string
+		wantErr     bool
+		wantDomains []string
+		wantHistory int
+	}{
+		{
+			name:        "pins the domain of the node",
+			nodeName:    "n3",
+			wantDomains: []string{"z2"},
+			wantHistory: 1,
+		},
+		{
+			name:     "node without the topology label leaves the binding unchanged",
+			nodeName: "n4",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			nodes := append(defaultNodes(), testutil.MakeNode("n4", ""))
+			pl := newTestPlugin(ctx, t, testArgs(func(args *configv1beta2.StickyPodArgs) { args.RecordOnBind = ptr.To(true) }), nodes, nil)
+			binding := &v1alpha1.StickyBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "statefulset-web", Namespace: testutil.Namespace},
+				Spec:       v1alpha1.StickyBindingSpec{Target: v1alpha1.StickyTarget{Kind: "StatefulSet", Name: "web"}},
+			}
+			client := stickyfake.NewSimpleClientset(binding)
+			pl.bindingClient = client
+			r := &stickyState{topologyKey: testutil.ZoneKey, ownerKind: "StatefulSet", ownerName: "web", binding: binding.Name}
+
+			err := pl.recordBinding(ctx, testutil.MakePod("web-0", "StatefulSet", "web"), r, tt.nodeName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("recordBinding() error = %v, want error %v", err, tt.wantErr)
+			}
+			got, err := client.SchedulingV1alpha1().StickyBindings(testutil.Namespace).Get(ctx, binding.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantDomains, got.Spec.Domains); diff != "" {
+				t.Errorf("domains (-want +got):\n%s", diff)
+			}
+			if len(got.Spec.NodeNames) != 0 {
+				t.Errorf("node names = %v, want none", got.Spec.NodeNames)
+			}
+			if len(got.Status.History) != tt.wantHistory {
+				t.Errorf("history = %v, want %d records", got.Status.History, tt.wantHistory)
+			}
+		})
+	}
+}
+
+func TestMaxReplicasPerNode(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	args := testArgs(func(args *configv1beta2.StickyPodArgs) {
+		args.MaxReplicasPerNode = ptr.To[int32](1)
+	})
+	// web-0 already runs on n1
+	running := testutil.OnNode(testutil.MakePod("web-0", "ReplicaSet", "web"), "n1")
+	pl := newTestPlugin(ctx, t, args, defaultNodes(), []*v1.Pod{running},
+		testutil.MakeOwner("ReplicaSet", "web", map[string]string{"sticky-nodes": "n1,n2"}))
+
+	podA, podB := testutil.MakePod("web-1", "ReplicaSet", "web"), testutil.MakePod("web-2", "ReplicaSet", "web")
+	stateA, stateB := framework.NewCycleState(), framework.NewCycleState()
+	for _, c := range []struct {
+		pod   *v1.Pod
+		state *framework.CycleState
+	}{{podA, stateA}, {podB, stateB}} {
+		if _, status := pl.PreFilter(ctx, c.state, c.pod); !status.IsSuccess() {
+			t.Fatalf("PreFilter %s: %v", c.pod.Name, status)
+		}
+	}
+
+	n1, _ := pl.snapshot.NodeInfos().Get("n1")
+	if status := pl.Filter(ctx, stateA, podA, n1); status.Code() != framework.Unschedulable {
+		t.Errorf("Filter on the full n1 = %v, want Unschedulable", status)
+	}
+	n2, _ := pl.snapshot.NodeInfos().Get("n2")
+	if status := pl.Filter(ctx, stateA, podA, n2); !status.IsSuccess() {
+		t.Fatalf("Filter web-1 on n2: %v", status)
+	}
+
+	// web-1 reserved n2, the scheduler assumes it into the NodeInfo web-2 is filtered with
+	n2.AddPod(testutil.OnNode(podA.DeepCopy(), "n2"))
+	if status := pl.Filter(ctx, stateB, podB, n2); status.Code() != framework.Unschedulable {
+		t.Errorf("Filter web-2 on n2 assumed by web-1 = %v, want Unschedulable", status)
+	}
+	if status := pl.Filter(ctx, stateA, podA, n2); !status.IsSuccess() {
+		t.Errorf("Filter web-1 on n2 it is assumed on: %v", status)
+	}
+}
+
+func TestRecordPreFilter(t *testing.T) {
+	RegisterMetrics()
+	tests := []struct {
+		name        string
+		s           *stickyState
+		status      *framework.Status
+		wantOutcome string
+		wantPending bool
+	}{
+		{
+			name:        "sticky nodes not available",
+			s:           &stickyState{nodeExists: true, NodeNames: []string{"n1"}},
+			status:      framework.NewStatus(framework.UnschedulableAndUnresolvable, "sticky nodes not available"),
+			wantOutcome: outcomeStickyNodeMissing,
+			wantPending: true,
+		},
+		{
+			name:        "local PVs not on the sticky nodes",
+			s:           &stickyState{nodeExists: true, NodeNames: []string{"n1"}, volumeConflict: true},
+			status:      framework.NewStatus(framework.UnschedulableAndUnresolvable, "local PV pv-0 is on nodes [n2], not in sticky nodes n1"),
+			wantOutcome: outcomeLocalVolumeConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &StickyPod{pending: newPendingTracker()}
+			pod := testutil.MakePod("web-0", "StatefulSet", "web")
+			before, _ := metricstestutil.GetCounterMetricValue(prefilterOutcomes.WithLabelValues(tt.wantOutcome))
+
+			pl.recordPreFilter(pod, tt.s, tt.status, "")
+			after, _ := metricstestutil.GetCounterMetricValue(prefilterOutcomes.WithLabelValues(tt.wantOutcome))
+			if after-before != 1 {
+				t.Errorf("outcome %s counted %v times, want 1", tt.wantOutcome, after-before)
+			}
+			if got := pl.pending.get(pod.UID) != nil; got != tt.wantPending {
+				t.Errorf("pending = %v, want %v", got, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package sticky
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Annotation key on the owner, value is a node label key (e.g. topology.kubernetes.io/zone
+	// or a rack label) the pod sticks to instead of exact node names.
+	// Defaults to StickyPodArgs.TopologyKey.
+	TopologyKeyAnnotationKey = "sticky-topology-key"
+	// Annotation key on the owner, value is the topology domains the pod sticks to, i.e. the
+	// node label values of the topology key, comma separated.
+	DomainsAnnotationKey = "sticky-domains"
+)
+
+// topologyKeyFor returns the topology key the owner sticks to, empty for node name stickiness.
+func (pl *StickyPod) topologyKeyFor(annotations map[string]string) string {
+	if v, ok := annotations[TopologyKeyAnnotationKey]; ok {
+		return v
+	}
+	return *pl.args.TopologyKey
+}
+
+// checkStickyDomains returns true if at least one available node of the snapshot is in the domains,
+// otherwise it returns why the nodes of the domains are not available.
+func (pl *StickyPod) checkStickyDomains(key string, domains []string) ([]string, bool) {
+	nodeInfos, err := pl.Handler.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return []string{err.Error()}, false
+	}
+
+	var reasons []string
+	for _, nodeInfo := range nodeInfos {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		if _, ok := domainIndex(node, key, domains); !ok {
+			continue
+		}
+		if node.Spec.Unschedulable {
+			reasons = append(reasons, fmt.Sprintf("%s cordoned", node.Name))
+			continue
+		}
+		if !isNodeReady(node) {
+			reasons = append(reasons, fmt.Sprintf("%s NotReady", node.Name))
+			continue
+		}
+		return nil, true
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "no node found")
+	}
+	return reasons, false
+}
+
+// nodeDomain returns the value of the topology key label of the node.
+func (pl *StickyPod) nodeDomain(nodeName, key string) (string, error) {
+	nodeInfo, err := pl.Handler.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil {
+		return "", err
+	}
+	v, ok := nodeInfo.Node().Labels[key]
+	if !ok {
+		return "", fmt.Errorf("node %s has no label %s", nodeName, key)
+	}
+	return v, nil
+}
+
+// domainIndex returns the position of the topology key label value of the node in domains.
+func domainIndex(node *v1.Node, key string, domains []string) (int, bool) {
+	v, ok := node.Labels[key]
+	if !ok {
+		return 0, false
+	}
+	for i, d := range domains {
+		if d == v {
+			return i, true
+		}
+	}
+	return 0, false
+}
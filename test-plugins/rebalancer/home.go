@@ -0,0 +1,190 @@
+package rebalancer
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	resourcehelper "k8s.io/component-helpers/resource"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/apis/sticky/v1alpha1"
+	"test-plugins/plugins/sticky"
+)
+
+// home is where the pods of an owner stick to: node names, or the domains of a topology key.
+type home struct {
+	nodeNames   []string
+	topologyKey string
+	domains     []string
+	// preferred 为 true 时 pod 只是优先放在 home，跑到别处也不驱逐
+	preferred bool
+}
+
+// homeOf returns the stickiness of the pod read the way StickyPod does: the StickyBinding of the
+// pod or of its owner first when bindings are used, then the owner annotations. Stickiness StickyPod
+// derives from the local PVs of the pod is not read, such a pod can't run away from its PVs.
+func (r *Rebalancer) homeOf(pod *v1.Pod, ref *metav1.OwnerReference, annotations map[string]string) (*home, bool) {
+	if r.bindingLister != nil {
+		if b := r.bindingFor(pod, ref); b != nil {
+			h := &home{topologyKey: b.Spec.TopologyKey, preferred: r.isPreferred(string(b.Spec.Mode))}
+			if h.topologyKey == "" {
+				h.topologyKey = r.topologyKey
+			}
+			switch {
+			case len(b.Spec.Domains) != 0 && h.topologyKey != "":
+				h.domains = b.Spec.Domains
+			case len(b.Spec.NodeNames) != 0:
+				h.nodeNames = b.Spec.NodeNames
+			default:
+				return nil, false
+			}
+			return h, true
+		}
+	}
+
+	h := &home{topologyKey: r.topologyKey, preferred: r.isPreferred(annotations[sticky.ModeAnnotationKey])}
+	if v, ok := annotations[sticky.TopologyKeyAnnotationKey]; ok {
+		h.topologyKey = v
+	}
+	if v, ok := annotations[sticky.DomainsAnnotationKey]; ok && v != "" && h.topologyKey != "" {
+		h.domains = strings.Split(v, ",")
+	} else if v, ok := annotations[r.annotationKey]; ok && v != "" {
+		h.nodeNames = strings.Split(v, ",")
+	} else {
+		return nil, false
+	}
+	return h, true
+}
+
+// isPreferred returns true if the sticky mode, or the default mode when empty, is preferred.
+func (r *Rebalancer) isPreferred(mode string) bool {
+	if mode == "" {
+		mode = string(r.defaultMode)
+	}
+	return configv1beta2.StickyMode(mode) == configv1beta2.StickyModePreferred
+}
+
+// bindingFor returns the StickyBinding of the pod itself, or else the one of its owner, nil if none.
+func (r *Rebalancer) bindingFor(pod *v1.Pod, ref *metav1.OwnerReference) *v1alpha1.StickyBinding {
+	bindings, err := r.bindingLister.StickyBindings(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+	var ownerBinding *v1alpha1.StickyBinding
+	for _, b := range bindings {
+		t := b.Spec.Target
+		if t.PodName != "" {
+			if t.PodName == pod.Name {
+				return b
+			}
+			continue
+		}
+		if ownerBinding == nil && t.Kind == ref.Kind && t.Name == ref.Name {
+			ownerBinding = b
+		}
+	}
+	return ownerBinding
+}
+
+// contains returns true if the node is one of the home nodes or in one of the home domains.
+func (h *home) contains(node *v1.Node) bool {
+	if len(h.domains) != 0 {
+		v, ok := node.Labels[h.topologyKey]
+		return ok && contains(h.domains, v)
+	}
+	return contains(h.nodeNames, node.Name)
+}
+
+func contains(values []string, v string) bool {
+	for _, existing := range values {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeUsage is what the pods bound to a node request.
+type nodeUsage struct {
+	// requests 按资源名记录，全部用 milli 值
+	requests map[v1.ResourceName]int64
+	pods     int64
+	// replicas 为每个 owner 在节点上的 pod 数，MaxReplicasPerNode 按它判断
+	replicas map[types.UID]int32
+}
+
+// clusterUsage is the usage of the nodes and the owners having a pod not bound yet.
+type clusterUsage struct {
+	nodes map[string]*nodeUsage
+	// pendingOwners 有 pod 还没调度完，上次驱逐的替身可能正要回家，这次不再驱逐
+	pendingOwners map[types.UID]bool
+}
+
+// newClusterUsage sums the requests of the pods by node, finished pods use nothing.
+func newClusterUsage(pods []*v1.Pod) *clusterUsage {
+	u := &clusterUsage{nodes: make(map[string]*nodeUsage), pendingOwners: make(map[types.UID]bool)}
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		ref := metav1.GetControllerOf(pod)
+		if pod.Spec.NodeName == "" {
+			if ref != nil && pod.DeletionTimestamp == nil {
+				u.pendingOwners[ref.UID] = true
+			}
+			continue
+		}
+		u.add(pod, ref, pod.Spec.NodeName)
+	}
+	return u
+}
+
+// add accounts the pod on the node.
+func (u *clusterUsage) add(pod *v1.Pod, ref *metav1.OwnerReference, nodeName string) {
+	n, ok := u.nodes[nodeName]
+	if !ok {
+		n = &nodeUsage{requests: make(map[v1.ResourceName]int64), replicas: make(map[types.UID]int32)}
+		u.nodes[nodeName] = n
+	}
+	for name, q := range podRequests(pod) {
+		n.requests[name] += q.MilliValue()
+	}
+	n.pods++
+	if ref != nil {
+		n.replicas[ref.UID]++
+	}
+}
+
+// fits returns true if the pod of the owner fits the node next to the pods bound to it: the
+// node has the resources the pod requests left, and holds less than maxReplicas pods of the owner
+// when maxReplicas is set.
+func (u *clusterUsage) fits(pod *v1.Pod, ownerUID types.UID, node *v1.Node, maxReplicas int32) bool {
+	n, ok := u.nodes[node.Name]
+	if !ok {
+		n = &nodeUsage{}
+	}
+	if maxReplicas > 0 && n.replicas[ownerUID] >= maxReplicas {
+		return false
+	}
+	if pods, ok := node.Status.Allocatable[v1.ResourcePods]; ok && n.pods+1 > pods.Value() {
+		return false
+	}
+	for name, q := range podRequests(pod) {
+		if q.IsZero() {
+			continue
+		}
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok || n.requests[name]+q.MilliValue() > allocatable.MilliValue() {
+			return false
+		}
+	}
+	return true
+}
+
+// podRequests returns what the pod requests, init containers and overhead included.
+func podRequests(pod *v1.Pod) v1.ResourceList {
+	return resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{})
+}
@@ -0,0 +1,323 @@
+package rebalancer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	stickyinformers "test-plugins/generated/informers/externalversions/sticky/v1alpha1"
+	stickylisters "test-plugins/generated/listers/sticky/v1alpha1"
+)
+
+// OptOutAnnotationKey on the pod owner, "false" keeps the pods where they are even when
+// they run away from their sticky nodes.
+const OptOutAnnotationKey = "sticky-rebalance"
+
+// Rebalancer evicts the pods running away from their sticky nodes once one of the sticky nodes
+// is available again and has room for the pod, so their owner recreates them and StickyPod places
+// them back home. An owner is evicted again only after a backoff, in case its pods keep landing
+// away from home.
+type Rebalancer struct {
+	client kubernetes.Interface
+	// annotationKey lists the sticky nodes, the same as StickyPodArgs.AnnotationKey.
+	annotationKey string
+	// topologyKey is the default topology key of sticky-domains, the same as StickyPodArgs.TopologyKey.
+	topologyKey string
+	// maxReplicasPerNode is the same as StickyPodArgs.MaxReplicasPerNode.
+	maxReplicasPerNode int32
+	// defaultMode is the mode of the owners without sticky-mode, the same as StickyPodArgs.DefaultMode.
+	defaultMode configv1beta2.StickyMode
+	// interval between two scans of the pods, a node becoming available triggers a scan too.
+	interval time.Duration
+	// dryRun sends the evictions with DryRun=All, PDBs are still checked but no pod is evicted.
+	dryRun  bool
+	limiter flowcontrol.RateLimiter
+	// backoff 按 owner 记录，驱逐后在退避时间内不再驱逐同一个 owner 的 pod
+	backoff *flowcontrol.Backoff
+
+	nodeLister    corelisters.NodeLister
+	podLister     corelisters.PodLister
+	bindingLister stickylisters.StickyBindingLister
+	synced        []cache.InformerSynced
+	trigger       chan struct{}
+}
+
+// Options configures the Rebalancer, the stickiness options match the StickyPodArgs of the scheduler.
+type Options struct {
+	AnnotationKey      string
+	TopologyKey        string
+	MaxReplicasPerNode int32
+	// DefaultMode is the mode of the owners without sticky-mode, empty means required.
+	DefaultMode configv1beta2.StickyMode
+	// Bindings reads the StickyBindings before the owner annotations, like StickyPod with
+	// UseStickyBindings. Nil reads the owner annotations only.
+	Bindings stickyinformers.StickyBindingInformer
+	Interval time.Duration
+	DryRun   bool
+	// EvictionQPS and EvictionBurst limit the evictions across all owners.
+	EvictionQPS   float32
+	EvictionBurst int
+	// OwnerBackoff is how long an owner waits after an eviction before the next one, doubled
+	// up to OwnerMaxBackoff while its pods keep running away.
+	OwnerBackoff    time.Duration
+	OwnerMaxBackoff time.Duration
+}
+
+// New creates the Rebalancer and registers its informers, they start with the factory.
+func New(client kubernetes.Interface, factory informers.SharedInformerFactory, opts Options) *Rebalancer {
+	nodeInformer := factory.Core().V1().Nodes()
+	podInformer := factory.Core().V1().Pods()
+	r := &Rebalancer{
+		client:             client,
+		annotationKey:      opts.AnnotationKey,
+		topologyKey:        opts.TopologyKey,
+		maxReplicasPerNode: opts.MaxReplicasPerNode,
+		defaultMode:        opts.DefaultMode,
+		interval:           opts.Interval,
+		dryRun:             opts.DryRun,
+		limiter:            flowcontrol.NewTokenBucketRateLimiter(opts.EvictionQPS, opts.EvictionBurst),
+		backoff:            flowcontrol.NewBackOff(opts.OwnerBackoff, opts.OwnerMaxBackoff),
+		nodeLister:         nodeInformer.Lister(),
+		podLister:          podInformer.Lister(),
+		synced:             []cache.InformerSynced{nodeInformer.Informer().HasSynced, podInformer.Informer().HasSynced},
+		trigger:            make(chan struct{}, 1),
+	}
+	if opts.Bindings != nil {
+		r.bindingLister = opts.Bindings.Lister()
+		r.synced = append(r.synced, opts.Bindings.Informer().HasSynced)
+	}
+
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if node, ok := obj.(*v1.Node); ok && isNodeAvailable(node) {
+				r.enqueue()
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, ok := oldObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			newNode, ok := newObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			// 节点恢复（uncordon 或重新 Ready）时马上扫一遍
+			if !isNodeAvailable(oldNode) && isNodeAvailable(newNode) {
+				klog.Infof("node %s is available again", newNode.Name)
+				r.enqueue()
+			}
+		},
+	})
+	return r
+}
+
+// Run scans the pods every interval and whenever a node becomes available, until ctx is done.
+func (r *Rebalancer) Run(ctx context.Context) error {
+	if !cache.WaitForCacheSync(ctx.Done(), r.synced...) {
+		return fmt.Errorf("informers not synced")
+	}
+	klog.Infof("sticky rebalancer started, interval %v, dry-run %v", r.interval, r.dryRun)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.scan(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// enqueue triggers a scan, pending triggers are merged into one.
+func (r *Rebalancer) enqueue() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// scan evicts the runaway pods, as many as the rate limiter allows.
+func (r *Rebalancer) scan(ctx context.Context) {
+	pods, err := r.podLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("list pods failed: %v", err)
+		return
+	}
+
+	r.backoff.GC()
+	usage := newClusterUsage(pods)
+
+	// 同一次扫描里 owner 只查一次，每个 owner 只驱逐一个 pod，等它回家后再看下一个
+	owners := make(map[string]metav1.Object)
+	evicted := make(map[string]bool)
+	for _, pod := range pods {
+		if !isRunningAndReady(pod) {
+			continue
+		}
+		ref := metav1.GetControllerOf(pod)
+		if ref == nil || !isMovableKind(ref.Kind) || usage.pendingOwners[ref.UID] {
+			continue
+		}
+
+		key := ref.Kind + "/" + pod.Namespace + "/" + ref.Name
+		if evicted[key] {
+			continue
+		}
+		if r.backoff.IsInBackOffSinceUpdate(key, r.backoff.Clock.Now()) {
+			klog.V(4).Infof("%s was evicted recently, its pods wait for the backoff", key)
+			continue
+		}
+		owner, ok := owners[key]
+		if !ok {
+			owner, err = r.getOwner(ctx, ref.Kind, pod.Namespace, ref.Name)
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					klog.Errorf("get %s of pod %s/%s failed: %v", key, pod.Namespace, pod.Name, err)
+				}
+				continue
+			}
+			owners[key] = owner
+		}
+
+		home, ok := r.runaway(pod, ref, owner.GetAnnotations(), usage)
+		if !ok {
+			continue
+		}
+		if !r.limiter.TryAccept() {
+			klog.V(4).Infof("eviction rate limit reached, the other runaway pods wait for the next scan")
+			return
+		}
+		if r.evict(ctx, pod, home) {
+			r.backoff.Next(key, r.backoff.Clock.Now())
+			// 替身 pod 会占用这个节点，同一次扫描里别的 owner 不能再算上这部分空间
+			usage.add(pod, ref, home)
+		}
+		evicted[key] = true
+	}
+}
+
+// runaway returns the sticky node the pod could go back to, false if the pod is at home, has no
+// sticky nodes, only prefers them, opted out or none of its sticky nodes is available with room
+// for the pod.
+func (r *Rebalancer) runaway(pod *v1.Pod, ref *metav1.OwnerReference, annotations map[string]string, usage *clusterUsage) (string, bool) {
+	if annotations[OptOutAnnotationKey] == "false" {
+		return "", false
+	}
+	h, ok := r.homeOf(pod, ref, annotations)
+	if !ok || h.preferred {
+		return "", false
+	}
+	current, err := r.nodeLister.Get(pod.Spec.NodeName)
+	if err != nil || h.contains(current) {
+		return "", false
+	}
+
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("list nodes failed: %v", err)
+		return "", false
+	}
+	for _, node := range nodes {
+		if !h.contains(node) || !isNodeAvailable(node) {
+			continue
+		}
+		if usage.fits(pod, ref.UID, node, r.maxReplicasPerNode) {
+			return node.Name, true
+		}
+		klog.V(4).Infof("pod %s/%s doesn't fit its sticky node %s, not evicted", pod.Namespace, pod.Name, node.Name)
+	}
+	return "", false
+}
+
+// evict evicts the pod through the Eviction API so the PodDisruptionBudgets are honoured,
+// a pod blocked by its PDB is retried at the next scan. It returns true if the pod is evicted.
+func (r *Rebalancer) evict(ctx context.Context, pod *v1.Pod, home string) bool {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	}
+	if r.dryRun {
+		eviction.DeleteOptions = &metav1.DeleteOptions{DryRun: []string{metav1.DryRunAll}}
+	}
+
+	err := r.client.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+	switch {
+	case err == nil:
+		klog.Infof("evicted pod %s/%s from %s, sticky node %s is available (dry-run %v)", pod.Namespace, pod.Name, pod.Spec.NodeName, home, r.dryRun)
+		return true
+	case apierrors.IsTooManyRequests(err):
+		klog.Infof("eviction of pod %s/%s blocked by its PodDisruptionBudget: %v", pod.Namespace, pod.Name, err)
+	case apierrors.IsNotFound(err):
+	default:
+		klog.Errorf("evict pod %s/%s failed: %v", pod.Namespace, pod.Name, err)
+	}
+	return false
+}
+
+// getOwner gets the pod owner, only its metadata is used.
+func (r *Rebalancer) getOwner(ctx context.Context, kind, ns, name string) (metav1.Object, error) {
+	opts := metav1.GetOptions{ResourceVersion: "0"}
+	switch kind {
+	case "StatefulSet":
+		return r.client.AppsV1().StatefulSets(ns).Get(ctx, name, opts)
+	case "ReplicaSet":
+		return r.client.AppsV1().ReplicaSets(ns).Get(ctx, name, opts)
+	case "ReplicationController":
+		return r.client.CoreV1().ReplicationControllers(ns).Get(ctx, name, opts)
+	default:
+		return nil, fmt.Errorf("unsupported owner kind %s", kind)
+	}
+}
+
+// isMovableKind returns true for owners recreating an evicted pod elsewhere. DaemonSet pods
+// are bound to their node and Job pods would lose their work, they are left alone.
+func isMovableKind(kind string) bool {
+	switch kind {
+	case "StatefulSet", "ReplicaSet", "ReplicationController":
+		return true
+	}
+	return false
+}
+
+// isRunningAndReady returns true if the pod is bound, running and Ready, pods still starting
+// or terminating are not moved.
+func isRunningAndReady(pod *v1.Pod) bool {
+	if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// isNodeAvailable returns true if the node is schedulable and Ready.
+func isNodeAvailable(node *v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package rebalancer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"test-plugins/apis/sticky/v1alpha1"
+	stickyfake "test-plugins/generated/clientset/versioned/fake"
+	stickyinformers "test-plugins/generated/informers/externalversions"
+	"test-plugins/test/testutil"
+)
+
+// makeNode returns a Ready node in the zone with 4 cpu allocatable.
+func makeNode(name, zone string) *v1.Node {
+	return testutil.AllocatableCPU(testutil.MakeNode(name, zone), "4")
+}
+
+// makePod returns a running and Ready pod of the StatefulSet on the node requesting the cpu.
+func makePod(name, owner, nodeName, cpu string) *v1.Pod {
+	return testutil.Running(testutil.OnNode(testutil.RequestCPU(testutil.MakePod(name, "StatefulSet", owner), cpu), nodeName))
+}
+
+// startRebalancer returns a synced Rebalancer on a fake API and the pods it evicts.
+func startRebalancer(ctx context.Context, t *testing.T, opts Options, bindings []runtime.Object, objs ...runtime.Object) (*Rebalancer, *[]string) {
+	t.Helper()
+	client := clientsetfake.NewClientset(objs...)
+	var evicted []string
+	client.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		evicted = append(evicted, action.(clienttesting.CreateAction).GetObject().(metav1.Object).GetName())
+		return true, nil, nil
+	})
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	var bindingFactory stickyinformers.SharedInformerFactory
+	if bindings != nil {
+		bindingFactory = stickyinformers.NewSharedInformerFactory(stickyfake.NewSimpleClientset(bindings...), 0)
+		opts.Bindings = bindingFactory.Scheduling().V1alpha1().StickyBindings()
+	}
+	opts.AnnotationKey = "sticky-nodes"
+	opts.Interval = time.Minute
+	opts.EvictionQPS, opts.EvictionBurst = 100, 100
+	r := New(client, factory, opts)
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	if bindingFactory != nil {
+		bindingFactory.Start(ctx.Done())
+		bindingFactory.WaitForCacheSync(ctx.Done())
+	}
+	return r, &evicted
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		bindings []runtime.Object
+		objs     []runtime.Object
+		want     []string
+	}{
+		{
+			name: "home node has room",
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+				makePod("web-0", "web", "n2", "1"),
+			},
+			want: []string{"web-0"},
+		},
+		{
+			name: "home node is full",
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+				testutil.MakeOwner("StatefulSet", "db", nil),
+				makePod("web-0", "web", "n2", "1"),
+				makePod("db-0", "db", "n1", "3500m"),
+			},
+		},
+		{
+			name: "home node holds MaxReplicasPerNode pods of the owner",
+			opts: Options{MaxReplicasPerNode: 1},
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+				makePod("web-0", "web", "n2", "1"),
+				makePod("web-1", "web", "n1", "1"),
+			},
+		},
+		{
+			name: "owner with a pending pod waits",
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+				makePod("web-0", "web", "n2", "1"),
+				makePod("web-1", "web", "", "1"),
+			},
+		},
+		{
+			name: "sticky domains",
+			opts: Options{TopologyKey: "zone"},
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"), makeNode("n3", "z1"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-domains": "z1"}),
+				testutil.MakeOwner("StatefulSet", "db", map[string]string{"sticky-domains": "z1"}),
+				makePod("web-0", "web", "n2", "1"),
+				makePod("db-0", "db", "n3", "1"),
+			},
+			want: []string{"web-0"},
+		},
+		{
+			name: "preferred mode",
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1", "sticky-mode": "preferred"}),
+				makePod("web-0", "web", "n2", "1"),
+			},
+		},
+		{
+			name: "preferred by default, required by the owner",
+			opts: Options{DefaultMode: "preferred"},
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1", "sticky-mode": "required"}),
+				testutil.MakeOwner("StatefulSet", "db", map[string]string{"sticky-nodes": "n1"}),
+				makePod("web-0", "web", "n2", "1"),
+				makePod("db-0", "db", "n2", "1"),
+			},
+			want: []string{"web-0"},
+		},
+		{
+			name: "preferred StickyBinding",
+			bindings: []runtime.Object{&v1alpha1.StickyBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "statefulset-web", Namespace: testutil.Namespace},
+				Spec: v1alpha1.StickyBindingSpec{
+					Target:    v1alpha1.StickyTarget{Kind: "StatefulSet", Name: "web"},
+					NodeNames: []string{"n1"},
+					Mode:      v1alpha1.StickyModePreferred,
+				},
+			}},
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", nil),
+				makePod("web-0", "web", "n2", "1"),
+			},
+		},
+		{
+			name: "StickyBinding before the owner annotation",
+			bindings: []runtime.Object{&v1alpha1.StickyBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "statefulset-web", Namespace: testutil.Namespace},
+				Spec: v1alpha1.StickyBindingSpec{
+					Target:    v1alpha1.StickyTarget{Kind: "StatefulSet", Name: "web"},
+					NodeNames: []string{"n2"},
+				},
+			}},
+			objs: []runtime.Object{
+				makeNode("n1", "z1"), makeNode("n2", "z2"),
+				testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+				makePod("web-0", "web", "n1", "1"),
+			},
+			want: []string{"web-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r, evicted := startRebalancer(ctx, t, tt.opts, tt.bindings, tt.objs...)
+			r.scan(ctx)
+			if diff := cmp.Diff(tt.want, *evicted); diff != "" {
+				t.Errorf("evicted pods (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScanOwnerBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, evicted := startRebalancer(ctx, t, Options{OwnerBackoff: time.Hour, OwnerMaxBackoff: time.Hour},
+		nil,
+		makeNode("n1", "z1"), makeNode("n2", "z2"),
+		testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"}),
+		makePod("web-0", "web", "n2", "1"),
+	)
+
+	// 驱逐被 fake API 吞掉，pod 还在 n2 上，就像替身 pod 又被放到了别处
+	r.scan(ctx)
+	r.scan(ctx)
+	if diff := cmp.Diff([]string{"web-0"}, *evicted); diff != "" {
+		t.Errorf("evicted pods (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,116 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Placements []Placement `json:"placements"`
+	// Before and After are the requested resources of the nodes before and after the pods are placed.
+	Before Utilization `json:"before"`
+	After  Utilization `json:"after"`
+}
+
+// Placement is where a pod would land.
+type Placement struct {
+	Pod string `json:"pod"`
+	// Node is empty when the pod is unschedulable.
+	Node  string `json:"node,omitempty"`
+	Score int64  `json:"score,omitempty"`
+	// Reason is why the pod is unschedulable.
+	Reason string `json:"reason,omitempty"`
+	// Rejected are the nodes filtered out and why.
+	Rejected map[string]string `json:"rejected,omitempty"`
+}
+
+// Utilization is the share of the allocatable CPU and memory requested by the pods.
+type Utilization struct {
+	CPU    float64                    `json:"cpu"`
+	Memory float64                    `json:"memory"`
+	Nodes  map[string]NodeUtilization `json:"nodes"`
+}
+
+// NodeUtilization is the Utilization of one node.
+type NodeUtilization struct {
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+	Pods   int     `json:"pods"`
+}
+
+// utilization computes the Utilization of the nodes in the snapshot.
+func (sim *Simulator) utilization() Utilization {
+	u := Utilization{Nodes: make(map[string]NodeUtilization)}
+	nodeInfos, _ := sim.snapshot.NodeInfos().List()
+	var cpu, memory, allocCPU, allocMemory int64
+	for _, nodeInfo := range nodeInfos {
+		u.Nodes[nodeInfo.Node().Name] = NodeUtilization{
+			CPU:    ratio(nodeInfo.Requested.MilliCPU, nodeInfo.Allocatable.MilliCPU),
+			Memory: ratio(nodeInfo.Requested.Memory, nodeInfo.Allocatable.Memory),
+			Pods:   len(nodeInfo.Pods),
+		}
+		cpu += nodeInfo.Requested.MilliCPU
+		memory += nodeInfo.Requested.Memory
+		allocCPU += nodeInfo.Allocatable.MilliCPU
+		allocMemory += nodeInfo.Allocatable.Memory
+	}
+	u.CPU, u.Memory = ratio(cpu, allocCPU), ratio(memory, allocMemory)
+	return u
+}
+
+func ratio(requested, allocatable int64) float64 {
+	if allocatable == 0 {
+		return 0
+	}
+	return float64(requested) / float64(allocatable)
+}
+
+// Print writes the report as tables.
+func (r *Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "POD\tNODE\tSCORE\tREASON")
+	for _, p := range r.Placements {
+		node := p.Node
+		if node == "" {
+			node = "<none>"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", p.Pod, node, p.Score, p.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, p := range r.Placements {
+		if len(p.Rejected) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s rejected by:\n", p.Pod)
+		for _, node := range sortedKeys(p.Rejected) {
+			fmt.Fprintf(w, "  %s: %s\n", node, p.Rejected[node])
+		}
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tPODS\tCPU\tMEMORY")
+	for _, node := range sortedKeys(r.After.Nodes) {
+		before, after := r.Before.Nodes[node], r.After.Nodes[node]
+		fmt.Fprintf(tw, "%s\t%d -> %d\t%.1f%% -> %.1f%%\t%.1f%% -> %.1f%%\n", node,
+			before.Pods, after.Pods, before.CPU*100, after.CPU*100, before.Memory*100, after.Memory*100)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%.1f%% -> %.1f%%\t%.1f%% -> %.1f%%\n",
+		r.Before.CPU*100, r.After.CPU*100, r.Before.Memory*100, r.After.Memory*100)
+	return tw.Flush()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,356 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+	extenderv1 "k8s.io/kube-scheduler/extender/v1"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/backend/cache"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/metrics"
+
+	"extender-scheduler/handler"
+	"test-plugins/plugins/sticky"
+)
+
+// Extender modes, they match the filterVerb of the extender in the scheduler config.
+const (
+	// ExtenderFilter runs the /filter and /prioritize logic of the extender.
+	ExtenderFilter = "filter"
+	// ExtenderAllInOne runs the /allinone logic, it keeps the best node only.
+	ExtenderAllInOne = "allinone"
+	// ExtenderNone doesn't run the extender.
+	ExtenderNone = "none"
+)
+
+// Options configures the Simulator.
+type Options struct {
+	// Profile is the scheduler profile, StickyPod is enabled on it if it isn't.
+	Profile *config.KubeSchedulerProfile
+	// Extender is how the extender logic is run, one of ExtenderFilter, ExtenderAllInOne and ExtenderNone.
+	Extender string
+	// ExtenderWeight multiplies the extender scores, same as the weight of the extender in the scheduler config.
+	ExtenderWeight int64
+}
+
+// Simulator places pods one after another into a snapshot, with the plugins of the profile,
+// StickyPod included, and the extender logic run in-process. Nothing is sent to a cluster.
+type Simulator struct {
+	opts     Options
+	client   *clientsetfake.Clientset
+	fwk      framework.Framework
+	cache    cache.Cache
+	snapshot *cache.Snapshot
+	extender *handler.Extender
+}
+
+// New builds the framework of the profile on a fake API holding the snapshot.
+func New(ctx context.Context, s *Snapshot, opts Options) (*Simulator, error) {
+	logger := klog.FromContext(ctx)
+	switch opts.Extender {
+	case ExtenderFilter, ExtenderAllInOne, ExtenderNone:
+	default:
+		return nil, fmt.Errorf("unknown extender mode %q", opts.Extender)
+	}
+	if err := checkStickyPodArgs(opts.Profile); err != nil {
+		return nil, err
+	}
+
+	// cache 和 framework 都会记 scheduler 的指标，没注册时指标是 nil
+	metrics.Register()
+	objs := append([]runtime.Object{}, s.Objects...)
+	schedCache := cache.New(ctx, 0)
+	for _, node := range s.Nodes {
+		objs = append(objs, node)
+		schedCache.AddNode(logger, node)
+	}
+	for _, pod := range s.Pods {
+		objs = append(objs, pod)
+		if err := schedCache.AddPod(logger, pod); err != nil {
+			return nil, fmt.Errorf("add pod %s: %w", klog.KObj(pod), err)
+		}
+	}
+	client := clientsetfake.NewClientset(objs...)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	snapshot := cache.NewEmptySnapshot()
+
+	registry := plugins.NewInTreeRegistry()
+	if err := registry.Merge(frameworkruntime.Registry{sticky.Name: sticky.NewPlugin}); err != nil {
+		return nil, err
+	}
+	fwk, err := frameworkruntime.NewFramework(ctx, registry, withStickyPod(opts.Profile),
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithInformerFactory(factory),
+		frameworkruntime.WithSnapshotSharedLister(snapshot),
+		// 不需要事件，Events 为 nil 的 FakeRecorder 丢掉所有事件
+		frameworkruntime.WithEventRecorder(&events.FakeRecorder{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create framework: %w", err)
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	return &Simulator{
+		opts:     opts,
+		client:   client,
+		fwk:      fwk,
+		cache:    schedCache,
+		snapshot: snapshot,
+		extender: &handler.Extender{},
+	}, nil
+}
+
+// checkStickyPodArgs rejects the StickyPod args the simulator can't run: StickyBindings
+// are read through a client of the cluster and aren't part of the snapshot.
+func checkStickyPodArgs(profile *config.KubeSchedulerProfile) error {
+	for _, pc := range profile.PluginConfig {
+		if pc.Name != sticky.Name {
+			continue
+		}
+		args, err := sticky.DecodeArgs(pc.Args)
+		if err != nil {
+			return err
+		}
+		if *args.UseStickyBindings {
+			return fmt.Errorf("%s arg useStickyBindings is not supported by the simulator, set it to false", sticky.Name)
+		}
+	}
+	return nil
+}
+
+// withStickyPod returns a copy of the profile with StickyPod enabled.
+func withStickyPod(profile *config.KubeSchedulerProfile) *config.KubeSchedulerProfile {
+	p := profile.DeepCopy()
+	if p.Plugins == nil {
+		p.Plugins = &config.Plugins{}
+	}
+	for _, set := range []config.PluginSet{p.Plugins.MultiPoint, p.Plugins.PreFilter, p.Plugins.Filter} {
+		for _, pl := range set.Enabled {
+			if pl.Name == sticky.Name {
+				return p
+			}
+		}
+	}
+	p.Plugins.MultiPoint.Enabled = append(p.Plugins.MultiPoint.Enabled, config.Plugin{Name: sticky.Name})
+	return p
+}
+
+// Run places the pods in order, each placed pod takes its resources before the next one
+// is scheduled. Unschedulable pods are reported and skipped.
+func (sim *Simulator) Run(ctx context.Context, pods []*v1.Pod) (*Report, error) {
+	logger := klog.FromContext(ctx)
+	if err := sim.cache.UpdateSnapshot(logger, sim.snapshot); err != nil {
+		return nil, err
+	}
+	report := &Report{Before: sim.utilization()}
+
+	for i, pod := range pods {
+		pod = pod.DeepCopy()
+		pod.Spec.NodeName = ""
+		if pod.Namespace == "" {
+			pod.Namespace = metav1.NamespaceDefault
+		}
+		if pod.UID == "" {
+			// 插件按 UID 记录 pod，dump 出来的模板可能没有
+			pod.UID = types.UID(fmt.Sprintf("simulated-%d", i))
+		}
+
+		placement, err := sim.schedule(ctx, pod)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", klog.KObj(pod), err)
+		}
+		report.Placements = append(report.Placements, *placement)
+		if err := sim.cache.UpdateSnapshot(logger, sim.snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	report.After = sim.utilization()
+	return report, nil
+}
+
+// schedule runs one scheduling cycle of the pod and binds it in the snapshot when it fits.
+func (sim *Simulator) schedule(ctx context.Context, pod *v1.Pod) (*Placement, error) {
+	placement := &Placement{Pod: klog.KObj(pod).String(), Rejected: make(map[string]string)}
+	state := framework.NewCycleState()
+
+	result, status, _ := sim.fwk.RunPreFilterPlugins(ctx, state, pod)
+	if !status.IsSuccess() {
+		placement.Reason = statusReason(status)
+		return placement, nil
+	}
+
+	nodeInfos, err := sim.snapshot.NodeInfos().List()
+	if err != nil {
+		return nil, err
+	}
+	var feasible []*framework.NodeInfo
+	for _, nodeInfo := range nodeInfos {
+		name := nodeInfo.Node().Name
+		if !result.AllNodes() && !result.NodeNames.Has(name) {
+			placement.Rejected[name] = "PreFilter: not in PreFilterResult"
+			continue
+		}
+		if status := sim.fwk.RunFilterPlugins(ctx, state, pod, nodeInfo); !status.IsSuccess() {
+			placement.Rejected[name] = statusReason(status)
+			continue
+		}
+		feasible = append(feasible, nodeInfo)
+	}
+	// 调度器选分数相同的节点是随机的，这里按名字排保证结果可以复现
+	sort.Slice(feasible, func(i, j int) bool { return feasible[i].Node().Name < feasible[j].Node().Name })
+
+	feasible, err = sim.extenderFilter(pod, feasible, placement)
+	if err != nil {
+		return nil, err
+	}
+	if len(feasible) == 0 {
+		placement.Reason = fmt.Sprintf("0/%d nodes are available", len(nodeInfos))
+		return placement, nil
+	}
+
+	nodeName, score, err := sim.selectHost(ctx, state, pod, feasible)
+	if err != nil {
+		return nil, err
+	}
+	if status := sim.fwk.RunReservePluginsReserve(ctx, state, pod, nodeName); !status.IsSuccess() {
+		sim.fwk.RunReservePluginsUnreserve(ctx, state, pod, nodeName)
+		placement.Reason = statusReason(status)
+		return placement, nil
+	}
+	if status := sim.fwk.RunPermitPlugins(ctx, state, pod, nodeName); !status.IsSuccess() {
+		// Wait 也当作失败，模拟器里没有其他 pod 会来放行
+		sim.fwk.RunReservePluginsUnreserve(ctx, state, pod, nodeName)
+		placement.Reason = statusReason(status)
+		return placement, nil
+	}
+
+	if err := sim.bind(ctx, pod, nodeName); err != nil {
+		return nil, err
+	}
+	sim.fwk.RunPostBindPlugins(ctx, state, pod, nodeName)
+	placement.Node, placement.Score = nodeName, score
+	return placement, nil
+}
+
+// extenderFilter runs the filter logic of the extender on the feasible nodes.
+func (sim *Simulator) extenderFilter(pod *v1.Pod, feasible []*framework.NodeInfo, placement *Placement) ([]*framework.NodeInfo, error) {
+	if sim.opts.Extender == ExtenderNone || len(feasible) == 0 {
+		return feasible, nil
+	}
+
+	var res *extenderv1.ExtenderFilterResult
+	var err error
+	if sim.opts.Extender == ExtenderAllInOne {
+		res, err = sim.extender.FilterOnlyOne(extenderArgs(pod, feasible))
+	} else {
+		res, err = sim.extender.Filter(extenderArgs(pod, feasible))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("extender %s: %w", sim.opts.Extender, err)
+	}
+
+	// extender 没有可选节点时原样返回所有节点，交给默认调度器继续
+	kept := make(map[string]bool)
+	if res.Nodes != nil {
+		for _, node := range res.Nodes.Items {
+			kept[node.Name] = true
+		}
+	}
+	var filtered []*framework.NodeInfo
+	for _, nodeInfo := range feasible {
+		name := nodeInfo.Node().Name
+		switch {
+		case res.FailedNodes[name] != "":
+			placement.Rejected[name] = "extender: " + res.FailedNodes[name]
+		case res.FailedAndUnresolvableNodes[name] != "":
+			placement.Rejected[name] = "extender: " + res.FailedAndUnresolvableNodes[name]
+		case !kept[name]:
+			placement.Rejected[name] = "extender: filtered out by " + sim.opts.Extender
+		default:
+			filtered = append(filtered, nodeInfo)
+		}
+	}
+	return filtered, nil
+}
+
+// selectHost scores the feasible nodes like the scheduler does and returns the best one.
+// A single feasible node is selected without scoring.
+func (sim *Simulator) selectHost(ctx context.Context, state *framework.CycleState, pod *v1.Pod, feasible []*framework.NodeInfo) (string, int64, error) {
+	if len(feasible) == 1 {
+		return feasible[0].Node().Name, 0, nil
+	}
+
+	totals := make(map[string]int64, len(feasible))
+	if status := sim.fwk.RunPreScorePlugins(ctx, state, pod, feasible); !status.IsSuccess() {
+		return "", 0, status.AsError()
+	}
+	scores, status := sim.fwk.RunScorePlugins(ctx, state, pod, feasible)
+	if !status.IsSuccess() {
+		return "", 0, status.AsError()
+	}
+	for _, s := range scores {
+		totals[s.Name] = s.TotalScore
+	}
+
+	if sim.opts.Extender == ExtenderFilter {
+		priorities, err := sim.extender.Prioritize(extenderArgs(pod, feasible))
+		if err != nil {
+			return "", 0, fmt.Errorf("extender prioritize: %w", err)
+		}
+		// 和 scheduler 一样把 extender 的分数按 weight 换算到 framework 的分数范围
+		for _, p := range *priorities {
+			totals[p.Host] += p.Score * sim.opts.ExtenderWeight * (framework.MaxNodeScore / extenderv1.MaxExtenderPriority)
+		}
+	}
+
+	best := feasible[0].Node().Name
+	for _, nodeInfo := range feasible[1:] {
+		if name := nodeInfo.Node().Name; totals[name] > totals[best] {
+			best = name
+		}
+	}
+	return best, totals[best], nil
+}
+
+// bind places the pod on the node in the cache and in the fake API.
+func (sim *Simulator) bind(ctx context.Context, pod *v1.Pod, nodeName string) error {
+	pod.Spec.NodeName = nodeName
+	if err := sim.cache.AddPod(klog.FromContext(ctx), pod); err != nil {
+		return err
+	}
+	_, err := sim.client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	return err
+}
+
+// extenderArgs returns the ExtenderArgs the scheduler sends to an extender without nodeCacheCapable.
+func extenderArgs(pod *v1.Pod, nodeInfos []*framework.NodeInfo) extenderv1.ExtenderArgs {
+	nodes := &v1.NodeList{}
+	for _, nodeInfo := range nodeInfos {
+		nodes.Items = append(nodes.Items, *nodeInfo.Node())
+	}
+	return extenderv1.ExtenderArgs{Pod: pod, Nodes: nodes}
+}
+
+// statusReason formats the status as <plugin>: <reasons>.
+func statusReason(status *framework.Status) string {
+	reason := strings.Join(status.Reasons(), ", ")
+	if status.Plugin() == "" {
+		return reason
+	}
+	return status.Plugin() + ": " + reason
+}
@@ -0,0 +1,107 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2/ktesting"
+	configv1 "k8s.io/kube-scheduler/config/v1"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/apis/config/scheme"
+	"k8s.io/utils/ptr"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/plugins/sticky"
+)
+
+func defaultProfile(t *testing.T) *config.KubeSchedulerProfile {
+	versioned := configv1.KubeSchedulerConfiguration{}
+	scheme.Scheme.Default(&versioned)
+	cfg := config.KubeSchedulerConfiguration{}
+	if err := scheme.Scheme.Convert(&versioned, &cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg.Profiles[0]
+}
+
+func TestSimulator(t *testing.T) {
+	tests := []struct {
+		name     string
+		extender string
+		// want are the nodes of the pods web-0, web-1 and batch of testdata/pods.yaml.
+		want []string
+	}{
+		{
+			// web 粘在 worker-3 上，web-1 放不下；batch 只能去带 GPU 标签的节点
+			name:     "filter",
+			extender: ExtenderFilter,
+			want:     []string{"worker-3", "", "worker-2"},
+		},
+		{
+			// allinone 只留 nvidia.GPU 最大的 worker-2
+			name:     "allinone",
+			extender: ExtenderAllInOne,
+			want:     []string{"worker-3", "", "worker-2"},
+		},
+		{
+			name:     "without extender",
+			extender: ExtenderNone,
+			want:     []string{"worker-3", "", "worker-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			snapshot, err := LoadSnapshot("testdata/cluster.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pods, err := LoadPods("testdata/pods.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(snapshot.Nodes) != 3 || len(snapshot.Pods) != 1 || len(snapshot.Objects) != 1 || len(pods) != 3 {
+				t.Fatalf("loaded %d nodes, %d pods, %d objects and %d pods to place",
+					len(snapshot.Nodes), len(snapshot.Pods), len(snapshot.Objects), len(pods))
+			}
+
+			sim, err := New(ctx, snapshot, Options{Profile: defaultProfile(t), Extender: tt.extender, ExtenderWeight: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := sim.Run(ctx, pods)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, p := range report.Placements {
+				got = append(got, p.Node)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("nodes (-want +got):\n%s", diff)
+			}
+			if reason := report.Placements[1].Rejected["worker-3"]; reason != "NodeResourcesFit: Insufficient cpu" {
+				t.Errorf("web-1 rejected by worker-3 for %q", reason)
+			}
+			if report.Before.CPU != 0.2 || report.After.CPU != 0.4 {
+				t.Errorf("cpu utilization %v -> %v, want 0.2 -> 0.4", report.Before.CPU, report.After.CPU)
+			}
+		})
+	}
+}
+
+func TestNewRejectsStickyBindings(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	profile := defaultProfile(t)
+	profile.PluginConfig = append(profile.PluginConfig, config.PluginConfig{
+		Name: sticky.Name,
+		Args: &configv1beta2.StickyPodArgs{UseStickyBindings: ptr.To(true)},
+	})
+
+	_, err := New(ctx, &Snapshot{}, Options{Profile: profile, Extender: ExtenderNone})
+	want := "StickyPod arg useStickyBindings is not supported by the simulator, set it to false"
+	if err == nil || err.Error() != want {
+		t.Errorf("New() error = %v, want %q", err, want)
+	}
+}
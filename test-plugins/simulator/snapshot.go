@@ -0,0 +1,136 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Snapshot is the cluster state the pods are placed into.
+type Snapshot struct {
+	Nodes []*v1.Node
+	// Pods are the pods already bound to a node, pending pods of the dump are dropped.
+	Pods []*v1.Pod
+	// Objects are the other objects the plugins read, e.g. the pod owners and PVCs.
+	Objects []runtime.Object
+}
+
+// LoadSnapshot reads the nodes, pods and other objects from the files, as dumped by
+// kubectl get -o json or -o yaml. A file may hold a List or several YAML documents.
+func LoadSnapshot(paths ...string) (*Snapshot, error) {
+	objs, err := loadObjects(paths...)
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{}
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *v1.Node:
+			s.Nodes = append(s.Nodes, o)
+		case *v1.Pod:
+			// 还没调度的 pod 不占资源，需要模拟的 pod 放在 -pods 里
+			if o.Spec.NodeName != "" {
+				s.Pods = append(s.Pods, o)
+			}
+		default:
+			s.Objects = append(s.Objects, obj)
+		}
+	}
+	return s, nil
+}
+
+// LoadPods reads the pods to place from the files, in the order they are listed.
+func LoadPods(paths ...string) ([]*v1.Pod, error) {
+	objs, err := loadObjects(paths...)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pod, ok := obj.(*v1.Pod)
+		if !ok {
+			return nil, fmt.Errorf("want pods only, got %T", obj)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// loadObjects decodes all objects of the files, the items of Lists are flattened.
+func loadObjects(paths ...string) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		fileObjs, err := decodeAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", path, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+// decodeAll decodes the JSON or YAML documents of r.
+func decodeAll(r io.Reader) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		raw := runtime.RawExtension{}
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			// 空的 YAML 文档
+			continue
+		}
+		decoded, err := decode(raw.Raw)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, decoded...)
+	}
+}
+
+// decode decodes one object, or the items of a list.
+func decode(data []byte) ([]runtime.Object, error) {
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !meta.IsListType(obj) {
+		return []runtime.Object{obj}, nil
+	}
+
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return nil, err
+	}
+	var objs []runtime.Object
+	for _, item := range items {
+		// kind: List 的 items 解出来是 runtime.Unknown
+		if unknown, ok := item.(*runtime.Unknown); ok {
+			decoded, err := decode(unknown.Raw)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, decoded...)
+			continue
+		}
+		objs = append(objs, item)
+	}
+	return objs, nil
+}
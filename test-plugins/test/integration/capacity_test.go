@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/noderesources"
+
+	quotav1alpha1 "test-plugins/apis/quota/v1alpha1"
+	"test-plugins/plugins/capacity"
+	"test-plugins/test/testutil"
+)
+
+// startCapacityScheduler runs a scheduler with NodeResourcesFit and CapacityQuota enabled.
+func startCapacityScheduler(ctx context.Context, t *testing.T, quotas ...*quotav1alpha1.ElasticQuota) *testCluster {
+	t.Helper()
+	fitArgs := &schedulerapi.NodeResourcesFitArgs{
+		ScoringStrategy: &schedulerapi.ScoringStrategy{
+			Type:      schedulerapi.LeastAllocated,
+			Resources: []schedulerapi.ResourceSpec{{Name: string(v1.ResourceCPU), Weight: 1}},
+		},
+	}
+	var objs []runtime.Object
+	for _, q := range quotas {
+		objs = append(objs, q)
+	}
+	return startProfile(ctx, t, []schedulerapi.Plugin{{Name: noderesources.Name}, {Name: capacity.Name}},
+		[]schedulerapi.PluginConfig{{Name: noderesources.Name, Args: fitArgs}}, objs...)
+}
+
+func TestCapacityQuotaMaxAndReclaim(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := startCapacityScheduler(ctx, t, testutil.MakeQuota("team-a", "quota", "2", "4"), testutil.MakeQuota("team-b", "quota", "2", "4"))
+	c.createNode(t, testutil.AllocatableCPU(testutil.MakeNode("node-1", "zone-a"), "4"))
+
+	// team-b 借用了 team-a 没用的 min，占满整个节点
+	var borrowed []*v1.Pod
+	for _, name := range []string{"b-0", "b-1", "b-2", "b-3"} {
+		pod := testutil.MakeCPUPod("team-b", name, "1")
+		c.createPod(t, pod)
+		c.waitForBound(t, pod)
+		borrowed = append(borrowed, pod)
+	}
+
+	overMax := testutil.MakeCPUPod("team-b", "b-4", "1")
+	c.createPod(t, overMax)
+	c.waitForUnschedulable(t, overMax)
+	if err := c.client.CoreV1().Pods(overMax.Namespace).Delete(ctx, overMax.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod %s: %v", overMax.Name, err)
+	}
+
+	// team-a 在 min 之内，抢回 team-b 借走的一个 cpu
+	reclaimer := testutil.MakeCPUPod("team-a", "a-0", "1")
+	c.createPod(t, reclaimer)
+	if node := c.waitForBound(t, reclaimer); node != "node-1" {
+		t.Errorf("pod %s bound to %s, want node-1", reclaimer.Name, node)
+	}
+
+	var preempted int
+	for _, pod := range borrowed {
+		if _, err := c.client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			preempted++
+		}
+	}
+	if preempted != 1 {
+		t.Errorf("%d pods of team-b preempted, want 1", preempted)
+	}
+}
+
+func TestCapacityQuotaBorrowingNeedsIdleMin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := startCapacityScheduler(ctx, t, testutil.MakeQuota("team-a", "quota", "1", "4"), testutil.MakeQuota("team-b", "quota", "1", "4"))
+	c.createNode(t, testutil.AllocatableCPU(testutil.MakeNode("node-1", "zone-a"), "8"))
+
+	a := testutil.MakeCPUPod("team-a", "a-0", "1")
+	c.createPod(t, a)
+	c.waitForBound(t, a)
+	b := testutil.MakeCPUPod("team-b", "b-0", "1")
+	c.createPod(t, b)
+	c.waitForBound(t, b)
+
+	// 两边都用满了 min，节点虽然还有空闲，也没有可以借的 min
+	borrower := testutil.MakeCPUPod("team-b", "b-1", "1")
+	c.createPod(t, borrower)
+	c.waitForUnschedulable(t, borrower)
+}
@@ -0,0 +1,138 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/nodeaffinity"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/nodeports"
+	"k8s.io/utils/ptr"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/plugins/gang"
+	"test-plugins/test/testutil"
+)
+
+// makeGroupPod returns a pod of the pod group with the host port, so each node fits one pod of the group.
+func makeGroupPod(name, group string, minMember int) *v1.Pod {
+	pod := testutil.MakeGroupPod(name, group, minMember)
+	pod.Spec.Containers[0].Ports = []v1.ContainerPort{{ContainerPort: 8080, HostPort: 8080}}
+	return pod
+}
+
+// startGangScheduler runs a scheduler with NodePorts and Gang enabled.
+func startGangScheduler(ctx context.Context, t *testing.T, args *configv1beta2.GangArgs, nodes ...*v1.Node) *testCluster {
+	t.Helper()
+	c := startProfile(ctx, t, []schedulerapi.Plugin{{Name: nodeports.Name}, {Name: gang.Name}},
+		[]schedulerapi.PluginConfig{{Name: gang.Name, Args: args}})
+	for _, node := range nodes {
+		c.createNode(t, node)
+	}
+	return c
+}
+
+// waitForAnyEvent waits until an event with the reason is recorded on any object.
+func (c *testCluster) waitForAnyEvent(t *testing.T, reason string) {
+	t.Helper()
+	err := wait.PollUntilContextTimeout(c.ctx, 100*time.Millisecond, waitTimeout, true, func(ctx context.Context) (bool, error) {
+		list, err := c.client.EventsV1().Events(testutil.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, e := range list.Items {
+			if e.Reason == reason {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("no %s event: %v", reason, err)
+	}
+}
+
+// boundPods returns the names of the pods bound to a node.
+func (c *testCluster) boundPods(t *testing.T) []string {
+	t.Helper()
+	list, err := c.client.CoreV1().Pods(testutil.Namespace).List(c.ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bound []string
+	for _, pod := range list.Items {
+		if pod.Spec.NodeName != "" {
+			bound = append(bound, fmt.Sprintf("%s on %s", pod.Name, pod.Spec.NodeName))
+		}
+	}
+	return bound
+}
+
+func TestGangWaitsForMembers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := startGangScheduler(ctx, t, &configv1beta2.GangArgs{}, testutil.MakeNode("n1", "z1"), testutil.MakeNode("n2", "z1"))
+
+	// 组里只有 1 个 pod，不能占节点
+	w0 := makeGroupPod("worker-0", "train", 2)
+	c.createPod(t, w0)
+	c.waitForUnschedulable(t, w0)
+
+	w1 := makeGroupPod("worker-1", "train", 2)
+	c.createPod(t, w1)
+	if n0, n1 := c.waitForBound(t, w0), c.waitForBound(t, w1); n0 == n1 {
+		t.Errorf("both pods bound to %s", n0)
+	}
+}
+
+func TestGangActivatedByNewMember(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := startProfile(ctx, t, []schedulerapi.Plugin{{Name: nodeaffinity.Name}, {Name: gang.Name}},
+		[]schedulerapi.PluginConfig{
+			{Name: nodeaffinity.Name, Args: &schedulerapi.NodeAffinityArgs{}},
+			{Name: gang.Name, Args: &configv1beta2.GangArgs{}},
+		}, testutil.MakeNode("n1", "z1"))
+
+	w0 := makeGroupPod("worker-0", "train", 2)
+	c.createPod(t, w0)
+	c.waitForUnschedulable(t, w0)
+
+	// worker-1 过不了 Filter，到不了 Permit，worker-0 只能靠 pod 创建事件重新激活
+	w1 := makeGroupPod("worker-1", "train", 2)
+	w1.Spec.NodeSelector = map[string]string{testutil.ZoneKey: "z2"}
+	c.createPod(t, w1)
+	c.waitForEvent(t, w0.Name, "GangWaiting")
+}
+
+func TestGangReleasesNodesOnTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := startGangScheduler(ctx, t, &configv1beta2.GangArgs{PermitWaitingTimeSeconds: ptr.To[int64](1)},
+		testutil.MakeNode("n1", "z1"), testutil.MakeNode("n2", "z1"))
+
+	// 3 个 pod 只有 2 个节点，占到节点的 pod 等不齐，超时后要把节点让出来
+	pods := []*v1.Pod{makeGroupPod("worker-0", "train", 3), makeGroupPod("worker-1", "train", 3), makeGroupPod("worker-2", "train", 3)}
+	for _, pod := range pods {
+		c.createPod(t, pod)
+	}
+	c.waitForAnyEvent(t, "GangRejected")
+	if bound := c.boundPods(t); len(bound) != 0 {
+		t.Fatalf("pods of an incomplete group bound: %v", bound)
+	}
+
+	c.createNode(t, testutil.MakeNode("n3", "z1"))
+	nodes := sets.New[string]()
+	for _, pod := range pods {
+		nodes.Insert(c.waitForBound(t, pod))
+	}
+	if nodes.Len() != 3 {
+		t.Errorf("pods bound to %v, want one per node", sets.List(nodes))
+	}
+}
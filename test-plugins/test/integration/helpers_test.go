@@ -0,0 +1,225 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/kubernetes/pkg/scheduler"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/config"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/defaultbinder"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/tainttoleration"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+	"k8s.io/kubernetes/pkg/scheduler/profile"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	quotav1alpha1 "test-plugins/apis/quota/v1alpha1"
+	quotafake "test-plugins/generated/clientset/versioned/fake"
+	"test-plugins/plugins/capacity"
+	"test-plugins/plugins/gang"
+	"test-plugins/plugins/sticky"
+	"test-plugins/test/testutil"
+)
+
+const (
+	schedulerName = "sticky-scheduler"
+
+	// waitTimeout is how long a test waits for the scheduler to bind or reject a pod.
+	waitTimeout = 30 * time.Second
+)
+
+// testCluster is a scheduler with the StickyPod profile running against a fake API.
+type testCluster struct {
+	ctx    context.Context
+	client *clientsetfake.Clientset
+}
+
+// startScheduler runs a scheduler with queuesort, defaultbinder, tainttoleration and StickyPod enabled.
+// The fake API binds a pod by setting its nodeName, owners are only read through the
+// typed client, so owner update events aren't delivered, node and pod events are.
+func startScheduler(ctx context.Context, t *testing.T, args *configv1beta2.StickyPodArgs, objs ...runtime.Object) *testCluster {
+	t.Helper()
+	return startProfile(ctx, t, []schedulerapi.Plugin{{Name: tainttoleration.Name}, {Name: sticky.Name}},
+		[]schedulerapi.PluginConfig{{Name: sticky.Name, Args: args}}, objs...)
+}
+
+// startProfile runs a scheduler with queuesort, defaultbinder and the plugins enabled,
+// the out of tree plugins are registered. ElasticQuotas in objs go to the fake quota API
+// CapacityQuota reads.
+func startProfile(ctx context.Context, t *testing.T, plugins []schedulerapi.Plugin, pluginConfig []schedulerapi.PluginConfig, objs ...runtime.Object) *testCluster {
+	t.Helper()
+
+	var kubeObjs, quotaObjs []runtime.Object
+	for _, obj := range objs {
+		if _, ok := obj.(*quotav1alpha1.ElasticQuota); ok {
+			quotaObjs = append(quotaObjs, obj)
+		} else {
+			kubeObjs = append(kubeObjs, obj)
+		}
+	}
+	quotaClient := quotafake.NewSimpleClientset(quotaObjs...)
+	client := clientsetfake.NewClientset(kubeObjs...)
+	client.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "binding" {
+			return false, nil, nil
+		}
+		binding := action.(clienttesting.CreateAction).GetObject().(*v1.Binding)
+		return true, binding, bindPod(client.Tracker(), binding)
+	})
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	broadcaster := events.NewBroadcaster(&events.EventSinkImpl{Interface: client.EventsV1()})
+	t.Cleanup(broadcaster.Shutdown)
+
+	sched, err := scheduler.New(ctx, client, factory, nil, profile.NewRecorderFactory(broadcaster),
+		scheduler.WithProfiles(schedulerapi.KubeSchedulerProfile{
+			SchedulerName: schedulerName,
+			Plugins: &schedulerapi.Plugins{
+				MultiPoint: schedulerapi.PluginSet{
+					Enabled: append([]schedulerapi.Plugin{{Name: queuesort.Name}, {Name: defaultbinder.Name}}, plugins...),
+				},
+			},
+			PluginConfig: pluginConfig,
+		}),
+		scheduler.WithFrameworkOutOfTreeRegistry(frameworkruntime.Registry{
+			sticky.Name: sticky.NewPlugin,
+			gang.Name:   gang.NewPlugin,
+			capacity.Name: func(ctx context.Context, _ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+				return capacity.New(ctx, handle, quotaClient)
+			},
+		}),
+		// 被拒绝的 pod 尽快重试，测试不用等默认的退避
+		scheduler.WithPodInitialBackoffSeconds(1),
+		scheduler.WithPodMaxBackoffSeconds(1),
+	)
+	if err != nil {
+		t.Fatalf("create scheduler: %v", err)
+	}
+
+	broadcaster.StartRecordingToSink(ctx.Done())
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	go sched.Run(ctx)
+
+	return &testCluster{ctx: ctx, client: client}
+}
+
+// bindPod sets the nodeName of the pod in the binding, like the binding subresource does.
+func bindPod(tracker clienttesting.ObjectTracker, binding *v1.Binding) error {
+	gvr := v1.SchemeGroupVersion.WithResource("pods")
+	obj, err := tracker.Get(gvr, binding.Namespace, binding.Name)
+	if err != nil {
+		return err
+	}
+	pod := obj.(*v1.Pod).DeepCopy()
+	if pod.Spec.NodeName != "" {
+		return apierrors.NewConflict(gvr.GroupResource(), pod.Name, nil)
+	}
+	pod.Spec.NodeName = binding.Target.Name
+	return tracker.Update(gvr, pod, pod.Namespace)
+}
+
+// createPod creates the pod of the test scheduler in the fake API.
+func (c *testCluster) createPod(t *testing.T, pod *v1.Pod) {
+	t.Helper()
+	testutil.WithScheduler(pod, schedulerName)
+	if _, err := c.client.CoreV1().Pods(pod.Namespace).Create(c.ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create pod %s: %v", pod.Name, err)
+	}
+}
+
+// ownerAnnotations returns the annotations of the owner.
+func (c *testCluster) ownerAnnotations(t *testing.T, kind, name string) map[string]string {
+	t.Helper()
+	obj, err := c.client.Tracker().Get(testutil.OwnerResources[kind], testutil.Namespace, name)
+	if err != nil {
+		t.Fatalf("get %s %s: %v", kind, name, err)
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return accessor.GetAnnotations()
+}
+
+// createNode creates the node in the fake API.
+func (c *testCluster) createNode(t *testing.T, node *v1.Node) {
+	t.Helper()
+	if _, err := c.client.CoreV1().Nodes().Create(c.ctx, node, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create node %s: %v", node.Name, err)
+	}
+}
+
+// waitForBound waits until the pod is bound and returns its node.
+func (c *testCluster) waitForBound(t *testing.T, pod *v1.Pod) string {
+	t.Helper()
+	var nodeName string
+	err := wait.PollUntilContextTimeout(c.ctx, 100*time.Millisecond, waitTimeout, true, func(ctx context.Context) (bool, error) {
+		got, err := c.client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		nodeName = got.Spec.NodeName
+		return nodeName != "", nil
+	})
+	if err != nil {
+		t.Fatalf("pod %s not bound: %v", pod.Name, err)
+	}
+	return nodeName
+}
+
+// waitForUnschedulable waits until the scheduler reports the pod unschedulable.
+func (c *testCluster) waitForUnschedulable(t *testing.T, pod *v1.Pod) {
+	t.Helper()
+	err := wait.PollUntilContextTimeout(c.ctx, 100*time.Millisecond, waitTimeout, true, func(ctx context.Context) (bool, error) {
+		got, err := c.client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if got.Spec.NodeName != "" {
+			return false, fmt.Errorf("pod bound to %s", got.Spec.NodeName)
+		}
+		for _, cond := range got.Status.Conditions {
+			if cond.Type == v1.PodScheduled && cond.Status == v1.ConditionFalse && cond.Reason == v1.PodReasonUnschedulable {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("pod %s not unschedulable: %v", pod.Name, err)
+	}
+}
+
+// waitForEvent waits until an event with the reason is recorded on the object.
+func (c *testCluster) waitForEvent(t *testing.T, name, reason string) {
+	t.Helper()
+	err := wait.PollUntilContextTimeout(c.ctx, 100*time.Millisecond, waitTimeout, true, func(ctx context.Context) (bool, error) {
+		list, err := c.client.EventsV1().Events(testutil.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, e := range list.Items {
+			if e.Regarding.Name == name && e.Reason == reason {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("no %s event on %s: %v", reason, name, err)
+	}
+}
@@ -0,0 +1,146 @@
+package integration
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2/ktesting"
+	"k8s.io/utils/ptr"
+
+	configv1beta2 "test-plugins/apis/config/v1beta2"
+	"test-plugins/plugins/sticky"
+	"test-plugins/test/testutil"
+)
+
+// TestStickyPod runs full scheduling cycles of pods of every owner kind, and the
+// fallbacks when the sticky nodes are gone.
+func TestStickyPod(t *testing.T) {
+	type testCase struct {
+		name        string
+		args        *configv1beta2.StickyPodArgs
+		kind        string
+		annotations map[string]string
+		// wantNodes are the nodes the pod may be bound to.
+		wantNodes []string
+		// wantRecorded means the node the pod is bound to is recorded on the owner.
+		wantRecorded bool
+		// wantEvent is the reason of an event expected on the pod, empty for none.
+		wantEvent string
+	}
+	testArgs := func(fns ...func(*configv1beta2.StickyPodArgs)) *configv1beta2.StickyPodArgs {
+		args := &configv1beta2.StickyPodArgs{SupportedKinds: testutil.AllKinds}
+		for _, fn := range fns {
+			fn(args)
+		}
+		return args
+	}
+	recordOnBind := func(args *configv1beta2.StickyPodArgs) { args.RecordOnBind = ptr.To(true) }
+
+	var tests []testCase
+	for _, kind := range testutil.AllKinds {
+		tests = append(tests,
+			testCase{
+				name:        kind + " sticks to its node",
+				args:        testArgs(),
+				kind:        kind,
+				annotations: map[string]string{"sticky-nodes": "n2"},
+				wantNodes:   []string{"n2"},
+				wantEvent:   "StickyPinned",
+			},
+			testCase{
+				name:         kind + " records the node it is bound to",
+				args:         testArgs(recordOnBind),
+				kind:         kind,
+				wantNodes:    []string{"n1", "n2", "n3"},
+				wantRecorded: true,
+				wantEvent:    "StickyRecorded",
+			})
+	}
+	tests = append(tests, []testCase{
+		{
+			name: "AnyNode falls back to any node",
+			args: testArgs(recordOnBind, func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicyAnyNode)
+			}),
+			kind:        "StatefulSet",
+			annotations: map[string]string{"sticky-nodes": "gone"},
+			wantNodes:   []string{"n1", "n2", "n3"},
+			wantEvent:   "StickyFallback",
+		},
+		{
+			name: "SameTopology falls back to the zone of the sticky node",
+			args: testArgs(func(args *configv1beta2.StickyPodArgs) {
+				args.MissingNodesPolicy = ptr.To(configv1beta2.MissingNodesPolicySameTopology)
+				args.FallbackTopologyKey = ptr.To(testutil.ZoneKey)
+			}),
+			kind:        "ReplicaSet",
+			annotations: map[string]string{"sticky-nodes": "n4"},
+			wantNodes:   []string{"n3"},
+			wantEvent:   "StickyFallback",
+		},
+		{
+			name:        "sticky domains",
+			args:        testArgs(),
+			kind:        "Job",
+			annotations: map[string]string{sticky.TopologyKeyAnnotationKey: testutil.ZoneKey, sticky.DomainsAnnotationKey: "z2"},
+			wantNodes:   []string{"n3"},
+		},
+		{
+			name:        "preferred sticky node",
+			args:        testArgs(),
+			kind:        "DaemonSet",
+			annotations: map[string]string{"sticky-nodes": "n1", sticky.ModeAnnotationKey: "preferred"},
+			wantNodes:   []string{"n1"},
+		},
+	}...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			owner := testutil.MakeOwner(tt.kind, "web", tt.annotations)
+			c := startScheduler(ctx, t, tt.args, owner,
+				testutil.MakeNode("n1", "z1"), testutil.MakeNode("n2", "z1"), testutil.MakeNode("n3", "z2"), testutil.NotReady(testutil.MakeNode("n4", "z2")))
+
+			pod := testutil.MakePod("web-0", tt.kind, "web")
+			c.createPod(t, pod)
+			nodeName := c.waitForBound(t, pod)
+			if !slices.Contains(tt.wantNodes, nodeName) {
+				t.Errorf("pod bound to %s, want one of %v", nodeName, tt.wantNodes)
+			}
+
+			if tt.wantEvent != "" {
+				c.waitForEvent(t, pod.Name, tt.wantEvent)
+			}
+			want := tt.annotations
+			if tt.wantRecorded {
+				want = map[string]string{"sticky-nodes": nodeName}
+			}
+			if diff := cmp.Diff(want, c.ownerAnnotations(t, tt.kind, "web")); diff != "" {
+				t.Errorf("owner annotations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestStickyPodWaitsForNode checks a pod waiting for its missing sticky node is scheduled
+// once the node joins.
+func TestStickyPodWaitsForNode(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	args := &configv1beta2.StickyPodArgs{SupportedKinds: testutil.AllKinds}
+	objs := []runtime.Object{
+		testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n9"}),
+		testutil.MakeNode("n1", "z1"),
+	}
+	c := startScheduler(ctx, t, args, objs...)
+
+	pod := testutil.MakePod("web-0", "StatefulSet", "web")
+	c.createPod(t, pod)
+	c.waitForUnschedulable(t, pod)
+
+	c.createNode(t, testutil.MakeNode("n9", "z1"))
+	if nodeName := c.waitForBound(t, pod); nodeName != "n9" {
+		t.Errorf("pod bound to %s, want n9", nodeName)
+	}
+}
@@ -0,0 +1,187 @@
+// Package testutil holds the nodes, pods, owners and quotas the plugin, rebalancer, webhook
+// and integration tests build their clusters from.
+package testutil
+
+import (
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	quotav1alpha1 "test-plugins/apis/quota/v1alpha1"
+)
+
+const (
+	// Namespace is the namespace of the pods and owners.
+	Namespace = "default"
+	// ZoneKey is the zone label of the nodes.
+	ZoneKey = "zone"
+
+	// GroupLabel and MinMemberAnnotation are the Gang defaults a group pod carries.
+	GroupLabel          = "pod-group.scheduling.toys.io/name"
+	MinMemberAnnotation = "pod-group.scheduling.toys.io/min-member"
+)
+
+// AllKinds are the owner kinds StickyPod supports, the tests enable all of them.
+var AllKinds = []string{"StatefulSet", "ReplicaSet", "DaemonSet", "Job", "ReplicationController"}
+
+// OwnerResources are the resources of the owner kinds.
+var OwnerResources = map[string]schema.GroupVersionResource{
+	"StatefulSet":           appsv1.SchemeGroupVersion.WithResource("statefulsets"),
+	"ReplicaSet":            appsv1.SchemeGroupVersion.WithResource("replicasets"),
+	"DaemonSet":             appsv1.SchemeGroupVersion.WithResource("daemonsets"),
+	"Job":                   batchv1.SchemeGroupVersion.WithResource("jobs"),
+	"ReplicationController": v1.SchemeGroupVersion.WithResource("replicationcontrollers"),
+}
+
+// MakeNode returns a Ready node in the zone, an empty zone means no zone label.
+func MakeNode(name, zone string) *v1.Node {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{v1.LabelHostname: name}},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+	if zone != "" {
+		node.Labels[ZoneKey] = zone
+	}
+	return node
+}
+
+// AllocatableCPU gives the node the cpu and 110 pods allocatable.
+func AllocatableCPU(node *v1.Node, cpu string) *v1.Node {
+	node.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourcePods: resource.MustParse("110")}
+	node.Status.Capacity = node.Status.Allocatable
+	return node
+}
+
+// Cordoned marks the node unschedulable.
+func Cordoned(node *v1.Node) *v1.Node {
+	node.Spec.Unschedulable = true
+	return node
+}
+
+// NotReady sets the Ready condition of the node to False and taints it,
+// like the node lifecycle controller does.
+func NotReady(node *v1.Node) *v1.Node {
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}
+	node.Spec.Taints = append(node.Spec.Taints, v1.Taint{Key: v1.TaintNodeNotReady, Effect: v1.TaintEffectNoSchedule})
+	return node
+}
+
+// OwnerUID is the UID of the owner the pods reference.
+func OwnerUID(kind, name string) types.UID {
+	return types.UID(kind + "-" + name)
+}
+
+// MakeOwner returns a pod owner of the kind with the annotations.
+func MakeOwner(kind, name string, annotations map[string]string) runtime.Object {
+	meta := metav1.ObjectMeta{Name: name, Namespace: Namespace, UID: OwnerUID(kind, name), Annotations: annotations}
+	switch kind {
+	case "StatefulSet":
+		return &appsv1.StatefulSet{ObjectMeta: meta}
+	case "ReplicaSet":
+		return &appsv1.ReplicaSet{ObjectMeta: meta}
+	case "DaemonSet":
+		return &appsv1.DaemonSet{ObjectMeta: meta}
+	case "Job":
+		return &batchv1.Job{ObjectMeta: meta}
+	case "ReplicationController":
+		return &v1.ReplicationController{ObjectMeta: meta}
+	}
+	panic("unknown owner kind " + kind)
+}
+
+// MakePod returns a pending pod controlled by the owner, no owner when kind is empty.
+func MakePod(name, kind, ownerName string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         Namespace,
+			UID:               types.UID("pod-" + name),
+			CreationTimestamp: metav1.Now(),
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: "app"}},
+		},
+	}
+	if kind != "" {
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: OwnerResources[kind].GroupVersion().String(),
+			Kind:       kind,
+			Name:       ownerName,
+			UID:        OwnerUID(kind, ownerName),
+			Controller: ptr.To(true),
+		}}
+	}
+	return pod
+}
+
+// MakeCPUPod returns a pod of the namespace requesting the cpu.
+func MakeCPUPod(namespace, name, cpu string) *v1.Pod {
+	pod := MakePod(name, "", "")
+	pod.Namespace = namespace
+	pod.UID = types.UID(namespace + "-" + name)
+	return RequestCPU(pod, cpu)
+}
+
+// MakeGroupPod returns a pending pod of the group needing minMember pods, no group when group is empty.
+func MakeGroupPod(name, group string, minMember int) *v1.Pod {
+	pod := MakePod(name, "", "")
+	if group != "" {
+		pod.Labels = map[string]string{GroupLabel: group}
+		pod.Annotations = map[string]string{MinMemberAnnotation: strconv.Itoa(minMember)}
+	}
+	return pod
+}
+
+// RequestCPU makes the container of the pod request the cpu.
+func RequestCPU(pod *v1.Pod, cpu string) *v1.Pod {
+	pod.Spec.Containers[0].Resources.Requests = v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}
+	return pod
+}
+
+// WithScheduler sets the schedulerName of the pod.
+func WithScheduler(pod *v1.Pod, schedulerName string) *v1.Pod {
+	pod.Spec.SchedulerName = schedulerName
+	return pod
+}
+
+// CreatedAgo moves the creation of the pod back in time.
+func CreatedAgo(pod *v1.Pod, d time.Duration) *v1.Pod {
+	pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-d))
+	return pod
+}
+
+// OnNode returns a copy of the pod bound to the node.
+func OnNode(pod *v1.Pod, nodeName string) *v1.Pod {
+	pod = pod.DeepCopy()
+	pod.Spec.NodeName = nodeName
+	return pod
+}
+
+// Running marks the pod running and Ready.
+func Running(pod *v1.Pod) *v1.Pod {
+	pod.Status.Phase = v1.PodRunning
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	return pod
+}
+
+// MakeQuota returns the ElasticQuota of the namespace with the min and max cpu.
+func MakeQuota(namespace, name, min, max string) *quotav1alpha1.ElasticQuota {
+	return &quotav1alpha1.ElasticQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: quotav1alpha1.ElasticQuotaSpec{
+			Min: v1.ResourceList{v1.ResourceCPU: resource.MustParse(min)},
+			Max: v1.ResourceList{v1.ResourceCPU: resource.MustParse(max)},
+		},
+	}
+}
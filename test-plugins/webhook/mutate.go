@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"test-plugins/plugins/sticky"
+)
+
+// patchOperation is one operation of a JSON patch.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// mutateOwner trims the sticky node and domain lists of the owner: spaces around the entries,
+// empty entries and duplicates are removed, e.g. "node-a, node-b," becomes "node-a,node-b".
+func (s *Server) mutateOwner(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed(nil)
+	}
+
+	meta, err := objectMeta(req.Object.Raw)
+	if err != nil {
+		return denied(http.StatusBadRequest, fmt.Sprintf("decode %s failed: %v", req.Kind.Kind, err))
+	}
+
+	var patch []patchOperation
+	for _, key := range []string{s.AnnotationKey, sticky.DomainsAnnotationKey} {
+		v, ok := meta.Annotations[key]
+		if !ok {
+			continue
+		}
+		trimmed := trimList(v)
+		if trimmed == v || trimmed == "" {
+			// 全是空项时不改，交给 validate 拒绝
+			continue
+		}
+		klog.Infof("trim %s of %s %s/%s: %q -> %q", key, req.Kind.Kind, req.Namespace, meta.Name, v, trimmed)
+		patch = append(patch, patchOperation{Op: "replace", Path: "/metadata/annotations/" + escapeJSONPointer(key), Value: trimmed})
+	}
+	return patched(patch)
+}
+
+// mutatePod sets the schedulerName of a pod whose owner is sticky to the StickyPod profile,
+// so workloads only need the sticky annotation and not a custom schedulerName in the template.
+func (s *Server) mutatePod(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if s.SchedulerName == "" || req.Operation != admissionv1.Create {
+		return allowed(nil)
+	}
+
+	pod := v1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+		return denied(http.StatusBadRequest, fmt.Sprintf("decode pod failed: %v", err))
+	}
+	if pod.Spec.SchedulerName != "" && pod.Spec.SchedulerName != v1.DefaultSchedulerName {
+		return allowed(nil)
+	}
+
+	ref := metav1.GetControllerOf(&pod)
+	if ref == nil {
+		return allowed(nil)
+	}
+	// 创建 pod 时 namespace 可能只在 request 里
+	ns := pod.Namespace
+	if ns == "" {
+		ns = req.Namespace
+	}
+	owner, err := s.ownerMeta(context.TODO(), ref.Kind, ns, ref.Name)
+	if err != nil {
+		// 不因为 webhook 自己的问题挡住 pod 创建
+		klog.Errorf("get %s %s/%s of pod failed: %v", ref.Kind, ns, ref.Name, err)
+		return allowed(nil)
+	}
+	if owner == nil || !s.isSticky(owner.GetAnnotations()) {
+		return allowed(nil)
+	}
+
+	klog.Infof("set schedulerName %s on pod of %s %s/%s", s.SchedulerName, ref.Kind, ns, ref.Name)
+	return patched([]patchOperation{{Op: "add", Path: "/spec/schedulerName", Value: s.SchedulerName}})
+}
+
+// isSticky returns true if the annotations pin the pods to nodes or topology domains.
+func (s *Server) isSticky(annotations map[string]string) bool {
+	_, nodes := annotations[s.AnnotationKey]
+	_, domains := annotations[sticky.DomainsAnnotationKey]
+	return nodes || domains
+}
+
+// ownerMeta returns the metadata of the pod owner, nil for kinds StickyPod doesn't read.
+func (s *Server) ownerMeta(ctx context.Context, kind, ns, name string) (metav1.Object, error) {
+	opts := metav1.GetOptions{ResourceVersion: "0"}
+	switch kind {
+	case "StatefulSet":
+		return s.Client.AppsV1().StatefulSets(ns).Get(ctx, name, opts)
+	case "ReplicaSet":
+		return s.Client.AppsV1().ReplicaSets(ns).Get(ctx, name, opts)
+	case "DaemonSet":
+		return s.Client.AppsV1().DaemonSets(ns).Get(ctx, name, opts)
+	case "Job":
+		return s.Client.BatchV1().Jobs(ns).Get(ctx, name, opts)
+	case "ReplicationController":
+		return s.Client.CoreV1().ReplicationControllers(ns).Get(ctx, name, opts)
+	default:
+		return nil, nil
+	}
+}
+
+// patched returns a response admitting the request with the JSON patch, if any.
+func patched(patch []patchOperation) *admissionv1.AdmissionResponse {
+	if len(patch) == 0 {
+		return allowed(nil)
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return denied(http.StatusInternalServerError, err.Error())
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	return &admissionv1.AdmissionResponse{Allowed: true, Patch: data, PatchType: &patchType}
+}
+
+// trimList removes the spaces around the entries, the empty entries and the duplicates.
+func trimList(value string) string {
+	var entries []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ",")
+}
+
+// escapeJSONPointer escapes a map key for a JSON patch path, see RFC 6901.
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Server validates the sticky annotations on the pod owners and mutates pods of sticky owners.
+type Server struct {
+	Client kubernetes.Interface
+	// Nodes lists the nodes the sticky nodes are looked up in, an admission doesn't call the apiserver for them.
+	Nodes corelisters.NodeLister
+	// AnnotationKey lists the sticky nodes, the same as StickyPodArgs.AnnotationKey.
+	AnnotationKey string
+	// MaxNodes is the longest sticky node list allowed, 0 means no limit.
+	MaxNodes int
+	// SchedulerName is the profile with StickyPod enabled, pods of sticky owners are mutated
+	// to use it. Empty disables the pod mutation.
+	SchedulerName string
+}
+
+// admitFunc handles one AdmissionRequest.
+type admitFunc func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+// Handler returns the http handler of the webhook endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	// 校验 owner 上的 sticky annotation
+	mux.HandleFunc("/validate", s.serve(s.validateOwner))
+	// 整理 owner 上的 sticky annotation，去掉空格和空项
+	mux.HandleFunc("/mutate", s.serve(s.mutateOwner))
+	// sticky owner 的 pod 设置 schedulerName
+	mux.HandleFunc("/mutate-pod", s.serve(s.mutatePod))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
+
+// serve decodes the AdmissionReview, calls admit and writes the response back.
+func (s *Server) serve(admit admitFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			klog.Errorf("read admission review failed: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+			klog.Errorf("decode admission review failed: %v", err)
+			http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		req := review.Request
+		klog.V(4).Infof("admit %s %s %s/%s", req.Operation, req.Kind.Kind, req.Namespace, req.Name)
+		resp := admit(req)
+		resp.UID = req.UID
+		review.Response = resp
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(&review); err != nil {
+			klog.Errorf("write admission review failed: %v", err)
+		}
+	}
+}
+
+// allowed returns a response admitting the request with the warnings.
+func allowed(warnings []string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true, Warnings: warnings}
+}
+
+// denied returns a response rejecting the request.
+func denied(code int32, msg string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &metav1.Status{Status: metav1.StatusFailure, Code: code, Reason: metav1.StatusReasonInvalid, Message: msg},
+	}
+}
+
+// objectMeta decodes only the metadata of the raw object, whatever its kind.
+func objectMeta(raw []byte) (*metav1.ObjectMeta, error) {
+	obj := struct {
+		metav1.ObjectMeta `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, err
+	}
+	return &obj.ObjectMeta, nil
+}
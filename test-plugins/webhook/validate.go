@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	"test-plugins/plugins/sticky"
+)
+
+// validateOwner checks the sticky annotations of a StatefulSet, ReplicaSet, Deployment or
+// any other owner. Malformed values are rejected, sticky nodes missing from the cluster
+// only produce warnings since the nodes may join later.
+func (s *Server) validateOwner(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allowed(nil)
+	}
+
+	meta, err := objectMeta(req.Object.Raw)
+	if err != nil {
+		return denied(http.StatusBadRequest, fmt.Sprintf("decode %s failed: %v", req.Kind.Kind, err))
+	}
+
+	errs, nodes := s.validateAnnotations(meta.Annotations)
+	if len(errs) != 0 {
+		klog.Infof("reject %s %s/%s: %s", req.Kind.Kind, req.Namespace, meta.Name, strings.Join(errs, "; "))
+		return denied(http.StatusUnprocessableEntity, strings.Join(errs, "; "))
+	}
+
+	return allowed(s.missingNodeWarnings(nodes))
+}
+
+// validateAnnotations returns what is wrong with the sticky annotations, and the sticky nodes
+// when they are well formed.
+func (s *Server) validateAnnotations(annotations map[string]string) (errs []string, nodes []string) {
+	if v, ok := annotations[s.AnnotationKey]; ok {
+		var listErrs []string
+		nodes, listErrs = parseList(s.AnnotationKey, v, validation.IsDNS1123Subdomain)
+		errs = append(errs, listErrs...)
+		if s.MaxNodes > 0 && len(nodes) > s.MaxNodes {
+			errs = append(errs, fmt.Sprintf("%s: lists %d nodes, at most %d are allowed", s.AnnotationKey, len(nodes), s.MaxNodes))
+		}
+	}
+
+	if v, ok := annotations[sticky.ModeAnnotationKey]; ok && v != "required" && v != "preferred" {
+		errs = append(errs, fmt.Sprintf("%s: %q must be required or preferred", sticky.ModeAnnotationKey, v))
+	}
+	if v, ok := annotations[sticky.OrderedAnnotationKey]; ok && v != "true" && v != "false" {
+		errs = append(errs, fmt.Sprintf("%s: %q must be true or false", sticky.OrderedAnnotationKey, v))
+	}
+
+	if v, ok := annotations[sticky.TopologyKeyAnnotationKey]; ok {
+		for _, msg := range validation.IsQualifiedName(v) {
+			errs = append(errs, fmt.Sprintf("%s: %q %s", sticky.TopologyKeyAnnotationKey, v, msg))
+		}
+	}
+	if v, ok := annotations[sticky.DomainsAnnotationKey]; ok {
+		_, listErrs := parseList(sticky.DomainsAnnotationKey, v, validation.IsValidLabelValue)
+		errs = append(errs, listErrs...)
+	}
+	return errs, nodes
+}
+
+// parseList splits the comma separated annotation value and validates each entry.
+// Entries with spaces around them or empty entries are errors, the mutating webhook trims them.
+func parseList(key, value string, validate func(string) []string) ([]string, []string) {
+	var errs []string
+	if value == "" {
+		return nil, []string{fmt.Sprintf("%s: must not be empty", key)}
+	}
+
+	entries := strings.Split(value, ",")
+	seen := make(map[string]bool, len(entries))
+	for i, entry := range entries {
+		if entry == "" {
+			errs = append(errs, fmt.Sprintf("%s: entry %d is empty", key, i))
+			continue
+		}
+		if trimmed := strings.TrimSpace(entry); trimmed != entry {
+			errs = append(errs, fmt.Sprintf("%s: entry %q has spaces around it, use %q", key, entry, trimmed))
+			continue
+		}
+		for _, msg := range validate(entry) {
+			errs = append(errs, fmt.Sprintf("%s: %q %s", key, entry, msg))
+		}
+		if seen[entry] {
+			errs = append(errs, fmt.Sprintf("%s: %q is listed more than once", key, entry))
+		}
+		seen[entry] = true
+	}
+	return entries, errs
+}
+
+// missingNodeWarnings returns a warning for each sticky node not found in the cluster.
+func (s *Server) missingNodeWarnings(nodes []string) []string {
+	var warnings []string
+	for _, name := range nodes {
+		_, err := s.Nodes.Get(name)
+		switch {
+		case apierrors.IsNotFound(err):
+			warnings = append(warnings, fmt.Sprintf("%s: node %s does not exist, pods sticking to it stay Pending", s.AnnotationKey, name))
+		case err != nil:
+			klog.Errorf("get node %s failed: %v", name, err)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,271 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+
+	"test-plugins/test/testutil"
+)
+
+// newServer returns a Server on a fake API holding the objects, its node lister synced.
+func newServer(ctx context.Context, t *testing.T, objs ...runtime.Object) *Server {
+	t.Helper()
+	client := clientsetfake.NewClientset(objs...)
+	factory := informers.NewSharedInformerFactory(client, 0)
+	s := &Server{
+		Client:        client,
+		Nodes:         factory.Core().V1().Nodes().Lister(),
+		AnnotationKey: "sticky-nodes",
+		MaxNodes:      2,
+		SchedulerName: "sticky-scheduler",
+	}
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+	return s
+}
+
+// review posts the object in an AdmissionReview to the path and returns the response.
+func review(t *testing.T, s *Server, path string, op admissionv1.Operation, kind string, obj runtime.Object) *admissionv1.AdmissionResponse {
+	t.Helper()
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "req",
+			Kind:      metav1.GroupVersionKind{Kind: kind},
+			Namespace: testutil.Namespace,
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s returned %d: %s", path, w.Code, w.Body.String())
+	}
+	var got admissionv1.AdmissionReview
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Response == nil || got.Response.UID != "req" {
+		t.Fatalf("%s responded %+v, want the response to request req", path, got.Response)
+	}
+	return got.Response
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		op           admissionv1.Operation
+		annotations  map[string]string
+		wantAllowed  bool
+		wantMessage  string
+		wantWarnings []string
+	}{
+		{
+			name:        "not sticky",
+			op:          admissionv1.Create,
+			wantAllowed: true,
+		},
+		{
+			name:        "existing nodes",
+			op:          admissionv1.Update,
+			annotations: map[string]string{"sticky-nodes": "n1,n2", "sticky-mode": "preferred", "sticky-ordered": "true"},
+			wantAllowed: true,
+		},
+		{
+			name:         "missing node is a warning",
+			op:           admissionv1.Create,
+			annotations:  map[string]string{"sticky-nodes": "n1,n3"},
+			wantAllowed:  true,
+			wantWarnings: []string{"sticky-nodes: node n3 does not exist, pods sticking to it stay Pending"},
+		},
+		{
+			name:        "empty list",
+			op:          admissionv1.Create,
+			annotations: map[string]string{"sticky-nodes": ""},
+			wantMessage: "sticky-nodes: must not be empty",
+		},
+		{
+			name:        "empty entry and spaces",
+			op:          admissionv1.Create,
+			annotations: map[string]string{"sticky-nodes": ", n2"},
+			wantMessage: `sticky-nodes: entry 0 is empty; sticky-nodes: entry " n2" has spaces around it, use "n2"`,
+		},
+		{
+			name:        "duplicate node",
+			op:          admissionv1.Create,
+			annotations: map[string]string{"sticky-nodes": "n1,n1"},
+			wantMessage: `sticky-nodes: "n1" is listed more than once`,
+		},
+		{
+			name:        "too many nodes",
+			op:          admissionv1.Create,
+			annotations: map[string]string{"sticky-nodes": "n1,n2,n3"},
+			wantMessage: "sticky-nodes: lists 3 nodes, at most 2 are allowed",
+		},
+		{
+			name:        "invalid mode, ordered and domain",
+			op:          admissionv1.Create,
+			annotations: map[string]string{"sticky-mode": "always", "sticky-ordered": "yes", "sticky-domains": "z1,-z2"},
+			wantMessage: `sticky-mode: "always" must be required or preferred; sticky-ordered: "yes" must be true or false; ` +
+				`sticky-domains: "-z2" a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', ` +
+				`and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', ` +
+				`regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name:        "delete is not checked",
+			op:          admissionv1.Delete,
+			annotations: map[string]string{"sticky-nodes": ""},
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := newServer(ctx, t, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}}, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n2"}})
+
+			resp := review(t, s, "/validate", tt.op, "StatefulSet", testutil.MakeOwner("StatefulSet", "web", tt.annotations))
+			if resp.Allowed != tt.wantAllowed {
+				t.Errorf("allowed = %v, want %v", resp.Allowed, tt.wantAllowed)
+			}
+			var msg string
+			if resp.Result != nil {
+				msg = resp.Result.Message
+			}
+			if msg != tt.wantMessage {
+				t.Errorf("message = %q, want %q", msg, tt.wantMessage)
+			}
+			if diff := cmp.Diff(tt.wantWarnings, resp.Warnings); diff != "" {
+				t.Errorf("warnings (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMutate(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantPatch   []patchOperation
+	}{
+		{
+			name:        "trimmed already",
+			annotations: map[string]string{"sticky-nodes": "n1,n2"},
+		},
+		{
+			name:        "spaces, empty entries and duplicates",
+			annotations: map[string]string{"sticky-nodes": " n1, n2,,n1,", "sticky-domains": "z1 ,z2"},
+			wantPatch: []patchOperation{
+				{Op: "replace", Path: "/metadata/annotations/sticky-nodes", Value: "n1,n2"},
+				{Op: "replace", Path: "/metadata/annotations/sticky-domains", Value: "z1,z2"},
+			},
+		},
+		{
+			name:        "only empty entries are left to validate",
+			annotations: map[string]string{"sticky-nodes": " , "},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := newServer(ctx, t)
+
+			resp := review(t, s, "/mutate", admissionv1.Create, "StatefulSet", testutil.MakeOwner("StatefulSet", "web", tt.annotations))
+			if !resp.Allowed {
+				t.Fatalf("denied: %v", resp.Result)
+			}
+			var patch []patchOperation
+			if resp.Patch != nil {
+				if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tt.wantPatch, patch); diff != "" {
+				t.Errorf("patch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMutatePod(t *testing.T) {
+	sticky := testutil.MakeOwner("StatefulSet", "web", map[string]string{"sticky-nodes": "n1"})
+	domains := testutil.MakeOwner("StatefulSet", "db", map[string]string{"sticky-domains": "z1"})
+	plain := testutil.MakeOwner("StatefulSet", "cache", nil)
+	setScheduler := []patchOperation{{Op: "add", Path: "/spec/schedulerName", Value: "sticky-scheduler"}}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		wantPatch []patchOperation
+	}{
+		{
+			name:      "sticky nodes",
+			pod:       testutil.MakePod("pod", "StatefulSet", "web"),
+			wantPatch: setScheduler,
+		},
+		{
+			name:      "sticky domains with the default scheduler",
+			pod:       testutil.WithScheduler(testutil.MakePod("pod", "StatefulSet", "db"), v1.DefaultSchedulerName),
+			wantPatch: setScheduler,
+		},
+		{
+			name: "owner not sticky",
+			pod:  testutil.MakePod("pod", "StatefulSet", "cache"),
+		},
+		{
+			name: "owner not found",
+			pod:  testutil.MakePod("pod", "StatefulSet", "gone"),
+		},
+		{
+			name: "no owner",
+			pod:  testutil.MakePod("pod", "", ""),
+		},
+		{
+			name: "other scheduler kept",
+			pod:  testutil.WithScheduler(testutil.MakePod("pod", "StatefulSet", "web"), "gpu-scheduler"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := newServer(ctx, t, sticky, domains, plain)
+
+			resp := review(t, s, "/mutate-pod", admissionv1.Create, "Pod", tt.pod)
+			if !resp.Allowed {
+				t.Fatalf("denied: %v", resp.Result)
+			}
+			var patch []patchOperation
+			if resp.Patch != nil {
+				if err := json.Unmarshal(resp.Patch, &patch); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tt.wantPatch, patch); diff != "" {
+				t.Errorf("patch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}